@@ -0,0 +1,192 @@
+package airports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// An airport Southwest flies to.
+type Airport struct {
+	Code      string // IATA code, e.g. "SFO"
+	Name      string
+	City      string
+	Latitude  float64
+	Longitude float64
+	Timezone  string // IANA time zone, e.g. "America/Los_Angeles"
+
+	// Metro area group shared with nearby airports, e.g. "BAY", or empty if none.
+	Metro string
+}
+
+// Prefix of metro area groups in airport lists, e.g. "@BAY".
+const MetroPrefix = "@"
+
+var registry = map[string]*Airport{
+	"ABQ": {Code: "ABQ", Name: "Albuquerque International Sunport", City: "Albuquerque, NM", Latitude: 35.0402, Longitude: -106.6092, Timezone: "America/Denver", Metro: ""},
+	"ALB": {Code: "ALB", Name: "Albany International", City: "Albany, NY", Latitude: 42.7483, Longitude: -73.8017, Timezone: "America/New_York", Metro: ""},
+	"AMA": {Code: "AMA", Name: "Rick Husband Amarillo International", City: "Amarillo, TX", Latitude: 35.2194, Longitude: -101.7059, Timezone: "America/Chicago", Metro: ""},
+	"ATL": {Code: "ATL", Name: "Hartsfield-Jackson Atlanta International", City: "Atlanta, GA", Latitude: 33.6407, Longitude: -84.4277, Timezone: "America/New_York", Metro: ""},
+	"AUA": {Code: "AUA", Name: "Queen Beatrix International", City: "Oranjestad, Aruba", Latitude: 12.5014, Longitude: -70.0152, Timezone: "America/Aruba", Metro: ""},
+	"AUS": {Code: "AUS", Name: "Austin-Bergstrom International", City: "Austin, TX", Latitude: 30.1975, Longitude: -97.6664, Timezone: "America/Chicago", Metro: ""},
+	"BDL": {Code: "BDL", Name: "Bradley International", City: "Hartford, CT", Latitude: 41.9389, Longitude: -72.6832, Timezone: "America/New_York", Metro: ""},
+	"BHM": {Code: "BHM", Name: "Birmingham-Shuttlesworth International", City: "Birmingham, AL", Latitude: 33.5629, Longitude: -86.7535, Timezone: "America/Chicago", Metro: ""},
+	"BNA": {Code: "BNA", Name: "Nashville International", City: "Nashville, TN", Latitude: 36.1263, Longitude: -86.6774, Timezone: "America/Chicago", Metro: ""},
+	"BOI": {Code: "BOI", Name: "Boise Airport", City: "Boise, ID", Latitude: 43.5644, Longitude: -116.2228, Timezone: "America/Boise", Metro: ""},
+	"BOS": {Code: "BOS", Name: "Boston Logan International", City: "Boston, MA", Latitude: 42.3656, Longitude: -71.0096, Timezone: "America/New_York", Metro: "BOS"},
+	"BUF": {Code: "BUF", Name: "Buffalo Niagara International", City: "Buffalo, NY", Latitude: 42.9405, Longitude: -78.7322, Timezone: "America/New_York", Metro: ""},
+	"BUR": {Code: "BUR", Name: "Hollywood Burbank", City: "Burbank, CA", Latitude: 34.2007, Longitude: -118.3585, Timezone: "America/Los_Angeles", Metro: "LA"},
+	"BWI": {Code: "BWI", Name: "Baltimore/Washington International", City: "Baltimore, MD", Latitude: 39.1774, Longitude: -76.6684, Timezone: "America/New_York", Metro: "DC"},
+	"BZE": {Code: "BZE", Name: "Philip S. W. Goldson International", City: "Belize City, Belize", Latitude: 17.5391, Longitude: -88.3082, Timezone: "America/Belize", Metro: ""},
+	"BZN": {Code: "BZN", Name: "Bozeman Yellowstone International", City: "Bozeman, MT", Latitude: 45.7775, Longitude: -111.1530, Timezone: "America/Denver", Metro: ""},
+	"CHS": {Code: "CHS", Name: "Charleston International", City: "Charleston, SC", Latitude: 32.8986, Longitude: -80.0405, Timezone: "America/New_York", Metro: ""},
+	"CLE": {Code: "CLE", Name: "Cleveland Hopkins International", City: "Cleveland, OH", Latitude: 41.4117, Longitude: -81.8498, Timezone: "America/New_York", Metro: ""},
+	"CLT": {Code: "CLT", Name: "Charlotte Douglas International", City: "Charlotte, NC", Latitude: 35.2140, Longitude: -80.9431, Timezone: "America/New_York", Metro: ""},
+	"CMH": {Code: "CMH", Name: "John Glenn Columbus International", City: "Columbus, OH", Latitude: 39.9980, Longitude: -82.8919, Timezone: "America/New_York", Metro: ""},
+	"COS": {Code: "COS", Name: "Colorado Springs Airport", City: "Colorado Springs, CO", Latitude: 38.8058, Longitude: -104.7008, Timezone: "America/Denver", Metro: ""},
+	"CRP": {Code: "CRP", Name: "Corpus Christi International", City: "Corpus Christi, TX", Latitude: 27.7704, Longitude: -97.5012, Timezone: "America/Chicago", Metro: ""},
+	"CUN": {Code: "CUN", Name: "Cancún International", City: "Cancún, Mexico", Latitude: 21.0365, Longitude: -86.8771, Timezone: "America/Cancun", Metro: ""},
+	"CVG": {Code: "CVG", Name: "Cincinnati/Northern Kentucky International", City: "Cincinnati, OH", Latitude: 39.0489, Longitude: -84.6678, Timezone: "America/New_York", Metro: ""},
+	"DAL": {Code: "DAL", Name: "Dallas Love Field", City: "Dallas, TX", Latitude: 32.8471, Longitude: -96.8518, Timezone: "America/Chicago", Metro: ""},
+	"DCA": {Code: "DCA", Name: "Ronald Reagan Washington National", City: "Washington, DC", Latitude: 38.8512, Longitude: -77.0402, Timezone: "America/New_York", Metro: "DC"},
+	"DEN": {Code: "DEN", Name: "Denver International", City: "Denver, CO", Latitude: 39.8561, Longitude: -104.6737, Timezone: "America/Denver", Metro: ""},
+	"DSM": {Code: "DSM", Name: "Des Moines International", City: "Des Moines, IA", Latitude: 41.5340, Longitude: -93.6631, Timezone: "America/Chicago", Metro: ""},
+	"DTW": {Code: "DTW", Name: "Detroit Metropolitan Wayne County", City: "Detroit, MI", Latitude: 42.2162, Longitude: -83.3554, Timezone: "America/Detroit", Metro: ""},
+	"ECP": {Code: "ECP", Name: "Northwest Florida Beaches International", City: "Panama City Beach, FL", Latitude: 30.3571, Longitude: -85.7956, Timezone: "America/Chicago", Metro: ""},
+	"ELP": {Code: "ELP", Name: "El Paso International", City: "El Paso, TX", Latitude: 31.8072, Longitude: -106.3778, Timezone: "America/Denver", Metro: ""},
+	"EUG": {Code: "EUG", Name: "Eugene Airport", City: "Eugene, OR", Latitude: 44.1246, Longitude: -123.2119, Timezone: "America/Los_Angeles", Metro: ""},
+	"FAT": {Code: "FAT", Name: "Fresno Yosemite International", City: "Fresno, CA", Latitude: 36.7762, Longitude: -119.7181, Timezone: "America/Los_Angeles", Metro: ""},
+	"FLL": {Code: "FLL", Name: "Fort Lauderdale-Hollywood International", City: "Fort Lauderdale, FL", Latitude: 26.0742, Longitude: -80.1506, Timezone: "America/New_York", Metro: "SFL"},
+	"GCM": {Code: "GCM", Name: "Owen Roberts International", City: "Grand Cayman, Cayman Islands", Latitude: 19.2928, Longitude: -81.3577, Timezone: "America/Cayman", Metro: ""},
+	"GEG": {Code: "GEG", Name: "Spokane International", City: "Spokane, WA", Latitude: 47.6199, Longitude: -117.5338, Timezone: "America/Los_Angeles", Metro: ""},
+	"GRR": {Code: "GRR", Name: "Gerald R. Ford International", City: "Grand Rapids, MI", Latitude: 42.8808, Longitude: -85.5228, Timezone: "America/Detroit", Metro: ""},
+	"GSP": {Code: "GSP", Name: "Greenville-Spartanburg International", City: "Greer, SC", Latitude: 34.8957, Longitude: -82.2189, Timezone: "America/New_York", Metro: ""},
+	"HDN": {Code: "HDN", Name: "Yampa Valley Airport", City: "Hayden, CO", Latitude: 40.4812, Longitude: -107.2176, Timezone: "America/Denver", Metro: ""},
+	"HNL": {Code: "HNL", Name: "Daniel K. Inouye International", City: "Honolulu, HI", Latitude: 21.3187, Longitude: -157.9225, Timezone: "Pacific/Honolulu", Metro: ""},
+	"HOU": {Code: "HOU", Name: "William P. Hobby", City: "Houston, TX", Latitude: 29.6454, Longitude: -95.2789, Timezone: "America/Chicago", Metro: ""},
+	"IAD": {Code: "IAD", Name: "Washington Dulles International", City: "Washington, DC", Latitude: 38.9531, Longitude: -77.4565, Timezone: "America/New_York", Metro: "DC"},
+	"ICT": {Code: "ICT", Name: "Wichita Dwight D. Eisenhower National", City: "Wichita, KS", Latitude: 37.6499, Longitude: -97.4331, Timezone: "America/Chicago", Metro: ""},
+	"IND": {Code: "IND", Name: "Indianapolis International", City: "Indianapolis, IN", Latitude: 39.7173, Longitude: -86.2944, Timezone: "America/Indiana/Indianapolis", Metro: ""},
+	"ISP": {Code: "ISP", Name: "Long Island MacArthur", City: "Islip, NY", Latitude: 40.7952, Longitude: -73.1002, Timezone: "America/New_York", Metro: "NYC"},
+	"ITO": {Code: "ITO", Name: "Hilo International", City: "Hilo, HI", Latitude: 19.7203, Longitude: -155.0485, Timezone: "Pacific/Honolulu", Metro: ""},
+	"JAN": {Code: "JAN", Name: "Jackson-Medgar Wiley Evers International", City: "Jackson, MS", Latitude: 32.3112, Longitude: -90.0759, Timezone: "America/Chicago", Metro: ""},
+	"JAX": {Code: "JAX", Name: "Jacksonville International", City: "Jacksonville, FL", Latitude: 30.4941, Longitude: -81.6879, Timezone: "America/New_York", Metro: ""},
+	"KOA": {Code: "KOA", Name: "Ellison Onizuka Kona International", City: "Kailua-Kona, HI", Latitude: 19.7388, Longitude: -156.0456, Timezone: "Pacific/Honolulu", Metro: ""},
+	"LAS": {Code: "LAS", Name: "Harry Reid International", City: "Las Vegas, NV", Latitude: 36.0840, Longitude: -115.1537, Timezone: "America/Los_Angeles", Metro: ""},
+	"LAX": {Code: "LAX", Name: "Los Angeles International", City: "Los Angeles, CA", Latitude: 33.9416, Longitude: -118.4085, Timezone: "America/Los_Angeles", Metro: "LA"},
+	"LBB": {Code: "LBB", Name: "Lubbock Preston Smith International", City: "Lubbock, TX", Latitude: 33.6636, Longitude: -101.8228, Timezone: "America/Chicago", Metro: ""},
+	"LGA": {Code: "LGA", Name: "LaGuardia", City: "New York, NY", Latitude: 40.7769, Longitude: -73.8740, Timezone: "America/New_York", Metro: "NYC"},
+	"LGB": {Code: "LGB", Name: "Long Beach Airport", City: "Long Beach, CA", Latitude: 33.8177, Longitude: -118.1516, Timezone: "America/Los_Angeles", Metro: "LA"},
+	"LIH": {Code: "LIH", Name: "Lihue Airport", City: "Lihue, HI", Latitude: 21.9760, Longitude: -159.3390, Timezone: "Pacific/Honolulu", Metro: ""},
+	"LIR": {Code: "LIR", Name: "Daniel Oduber Quirós International", City: "Liberia, Costa Rica", Latitude: 10.5933, Longitude: -85.5444, Timezone: "America/Costa_Rica", Metro: ""},
+	"LIT": {Code: "LIT", Name: "Clinton National", City: "Little Rock, AR", Latitude: 34.7294, Longitude: -92.2243, Timezone: "America/Chicago", Metro: ""},
+	"MAF": {Code: "MAF", Name: "Midland International", City: "Midland, TX", Latitude: 31.9425, Longitude: -102.2019, Timezone: "America/Chicago", Metro: ""},
+	"MBJ": {Code: "MBJ", Name: "Sangster International", City: "Montego Bay, Jamaica", Latitude: 18.5037, Longitude: -77.9134, Timezone: "America/Jamaica", Metro: ""},
+	"MCI": {Code: "MCI", Name: "Kansas City International", City: "Kansas City, MO", Latitude: 39.2976, Longitude: -94.7139, Timezone: "America/Chicago", Metro: ""},
+	"MCO": {Code: "MCO", Name: "Orlando International", City: "Orlando, FL", Latitude: 28.4312, Longitude: -81.3081, Timezone: "America/New_York", Metro: ""},
+	"MDW": {Code: "MDW", Name: "Chicago Midway International", City: "Chicago, IL", Latitude: 41.7868, Longitude: -87.7522, Timezone: "America/Chicago", Metro: "CHI"},
+	"MEM": {Code: "MEM", Name: "Memphis International", City: "Memphis, TN", Latitude: 35.0421, Longitude: -89.9792, Timezone: "America/Chicago", Metro: ""},
+	"MHT": {Code: "MHT", Name: "Manchester-Boston Regional", City: "Manchester, NH", Latitude: 42.9326, Longitude: -71.4357, Timezone: "America/New_York", Metro: "BOS"},
+	"MIA": {Code: "MIA", Name: "Miami International", City: "Miami, FL", Latitude: 25.7959, Longitude: -80.2870, Timezone: "America/New_York", Metro: "SFL"},
+	"MKE": {Code: "MKE", Name: "Milwaukee Mitchell International", City: "Milwaukee, WI", Latitude: 42.9472, Longitude: -87.8966, Timezone: "America/Chicago", Metro: ""},
+	"MSP": {Code: "MSP", Name: "Minneapolis-Saint Paul International", City: "Minneapolis, MN", Latitude: 44.8848, Longitude: -93.2223, Timezone: "America/Chicago", Metro: ""},
+	"MSY": {Code: "MSY", Name: "Louis Armstrong New Orleans International", City: "New Orleans, LA", Latitude: 29.9934, Longitude: -90.2580, Timezone: "America/Chicago", Metro: ""},
+	"MTJ": {Code: "MTJ", Name: "Montrose Regional", City: "Montrose, CO", Latitude: 38.5098, Longitude: -107.8940, Timezone: "America/Denver", Metro: ""},
+	"MYR": {Code: "MYR", Name: "Myrtle Beach International", City: "Myrtle Beach, SC", Latitude: 33.6797, Longitude: -78.9283, Timezone: "America/New_York", Metro: ""},
+	"NAS": {Code: "NAS", Name: "Lynden Pindling International", City: "Nassau, Bahamas", Latitude: 25.0390, Longitude: -77.4662, Timezone: "America/Nassau", Metro: ""},
+	"OAK": {Code: "OAK", Name: "Oakland International", City: "Oakland, CA", Latitude: 37.7126, Longitude: -122.2197, Timezone: "America/Los_Angeles", Metro: "BAY"},
+	"OGG": {Code: "OGG", Name: "Kahului Airport", City: "Kahului, HI", Latitude: 20.8986, Longitude: -156.4305, Timezone: "Pacific/Honolulu", Metro: ""},
+	"OKC": {Code: "OKC", Name: "Will Rogers World", City: "Oklahoma City, OK", Latitude: 35.3931, Longitude: -97.6007, Timezone: "America/Chicago", Metro: ""},
+	"OMA": {Code: "OMA", Name: "Eppley Airfield", City: "Omaha, NE", Latitude: 41.3032, Longitude: -95.8941, Timezone: "America/Chicago", Metro: ""},
+	"ONT": {Code: "ONT", Name: "Ontario International", City: "Ontario, CA", Latitude: 34.0560, Longitude: -117.6012, Timezone: "America/Los_Angeles", Metro: "LA"},
+	"ORD": {Code: "ORD", Name: "Chicago O'Hare International", City: "Chicago, IL", Latitude: 41.9742, Longitude: -87.9073, Timezone: "America/Chicago", Metro: "CHI"},
+	"ORF": {Code: "ORF", Name: "Norfolk International", City: "Norfolk, VA", Latitude: 36.8946, Longitude: -76.2012, Timezone: "America/New_York", Metro: ""},
+	"PBI": {Code: "PBI", Name: "Palm Beach International", City: "West Palm Beach, FL", Latitude: 26.6832, Longitude: -80.0956, Timezone: "America/New_York", Metro: "SFL"},
+	"PDX": {Code: "PDX", Name: "Portland International", City: "Portland, OR", Latitude: 45.5898, Longitude: -122.5951, Timezone: "America/Los_Angeles", Metro: ""},
+	"PHL": {Code: "PHL", Name: "Philadelphia International", City: "Philadelphia, PA", Latitude: 39.8744, Longitude: -75.2424, Timezone: "America/New_York", Metro: ""},
+	"PHX": {Code: "PHX", Name: "Phoenix Sky Harbor International", City: "Phoenix, AZ", Latitude: 33.4352, Longitude: -112.0101, Timezone: "America/Phoenix", Metro: ""},
+	"PIT": {Code: "PIT", Name: "Pittsburgh International", City: "Pittsburgh, PA", Latitude: 40.4915, Longitude: -80.2329, Timezone: "America/New_York", Metro: ""},
+	"PNS": {Code: "PNS", Name: "Pensacola International", City: "Pensacola, FL", Latitude: 30.4734, Longitude: -87.1866, Timezone: "America/Chicago", Metro: ""},
+	"PSP": {Code: "PSP", Name: "Palm Springs International", City: "Palm Springs, CA", Latitude: 33.8297, Longitude: -116.5067, Timezone: "America/Los_Angeles", Metro: ""},
+	"PUJ": {Code: "PUJ", Name: "Punta Cana International", City: "Punta Cana, Dominican Republic", Latitude: 18.5674, Longitude: -68.3634, Timezone: "America/Santo_Domingo", Metro: ""},
+	"PVD": {Code: "PVD", Name: "Rhode Island T. F. Green International", City: "Providence, RI", Latitude: 41.7267, Longitude: -71.4204, Timezone: "America/New_York", Metro: "BOS"},
+	"PVR": {Code: "PVR", Name: "Licenciado Gustavo Díaz Ordaz International", City: "Puerto Vallarta, Mexico", Latitude: 20.6801, Longitude: -105.2544, Timezone: "America/Bahia_Banderas", Metro: ""},
+	"RDU": {Code: "RDU", Name: "Raleigh-Durham International", City: "Raleigh, NC", Latitude: 35.8801, Longitude: -78.7880, Timezone: "America/New_York", Metro: ""},
+	"RIC": {Code: "RIC", Name: "Richmond International", City: "Richmond, VA", Latitude: 37.5052, Longitude: -77.3197, Timezone: "America/New_York", Metro: ""},
+	"RNO": {Code: "RNO", Name: "Reno-Tahoe International", City: "Reno, NV", Latitude: 39.4991, Longitude: -119.7681, Timezone: "America/Los_Angeles", Metro: ""},
+	"ROC": {Code: "ROC", Name: "Frederick Douglass Greater Rochester International", City: "Rochester, NY", Latitude: 43.1189, Longitude: -77.6724, Timezone: "America/New_York", Metro: ""},
+	"RSW": {Code: "RSW", Name: "Southwest Florida International", City: "Fort Myers, FL", Latitude: 26.5362, Longitude: -81.7552, Timezone: "America/New_York", Metro: ""},
+	"SAN": {Code: "SAN", Name: "San Diego International", City: "San Diego, CA", Latitude: 32.7338, Longitude: -117.1933, Timezone: "America/Los_Angeles", Metro: ""},
+	"SAT": {Code: "SAT", Name: "San Antonio International", City: "San Antonio, TX", Latitude: 29.5337, Longitude: -98.4698, Timezone: "America/Chicago", Metro: ""},
+	"SAV": {Code: "SAV", Name: "Savannah/Hilton Head International", City: "Savannah, GA", Latitude: 32.1276, Longitude: -81.2021, Timezone: "America/New_York", Metro: ""},
+	"SBA": {Code: "SBA", Name: "Santa Barbara Municipal", City: "Santa Barbara, CA", Latitude: 34.4262, Longitude: -119.8415, Timezone: "America/Los_Angeles", Metro: ""},
+	"SDF": {Code: "SDF", Name: "Louisville Muhammad Ali International", City: "Louisville, KY", Latitude: 38.1744, Longitude: -85.7360, Timezone: "America/Kentucky/Louisville", Metro: ""},
+	"SEA": {Code: "SEA", Name: "Seattle-Tacoma International", City: "Seattle, WA", Latitude: 47.4502, Longitude: -122.3088, Timezone: "America/Los_Angeles", Metro: ""},
+	"SFO": {Code: "SFO", Name: "San Francisco International", City: "San Francisco, CA", Latitude: 37.6213, Longitude: -122.3790, Timezone: "America/Los_Angeles", Metro: "BAY"},
+	"SJC": {Code: "SJC", Name: "San José Mineta International", City: "San Jose, CA", Latitude: 37.3639, Longitude: -121.9289, Timezone: "America/Los_Angeles", Metro: "BAY"},
+	"SJD": {Code: "SJD", Name: "Los Cabos International", City: "San José del Cabo, Mexico", Latitude: 23.1518, Longitude: -109.7211, Timezone: "America/Mazatlan", Metro: ""},
+	"SJO": {Code: "SJO", Name: "Juan Santamaría International", City: "San José, Costa Rica", Latitude: 9.9939, Longitude: -84.2088, Timezone: "America/Costa_Rica", Metro: ""},
+	"SJU": {Code: "SJU", Name: "Luis Muñoz Marín International", City: "San Juan, PR", Latitude: 18.4394, Longitude: -66.0018, Timezone: "America/Puerto_Rico", Metro: ""},
+	"SLC": {Code: "SLC", Name: "Salt Lake City International", City: "Salt Lake City, UT", Latitude: 40.7899, Longitude: -111.9791, Timezone: "America/Denver", Metro: ""},
+	"SMF": {Code: "SMF", Name: "Sacramento International", City: "Sacramento, CA", Latitude: 38.6951, Longitude: -121.5908, Timezone: "America/Los_Angeles", Metro: ""},
+	"SNA": {Code: "SNA", Name: "John Wayne", City: "Santa Ana, CA", Latitude: 33.6762, Longitude: -117.8675, Timezone: "America/Los_Angeles", Metro: "LA"},
+	"SRQ": {Code: "SRQ", Name: "Sarasota Bradenton International", City: "Sarasota, FL", Latitude: 27.3954, Longitude: -82.5544, Timezone: "America/New_York", Metro: ""},
+	"STL": {Code: "STL", Name: "St. Louis Lambert International", City: "St. Louis, MO", Latitude: 38.7487, Longitude: -90.3700, Timezone: "America/Chicago", Metro: ""},
+	"STT": {Code: "STT", Name: "Cyril E. King", City: "Charlotte Amalie, US Virgin Islands", Latitude: 18.3373, Longitude: -64.9734, Timezone: "America/St_Thomas", Metro: ""},
+	"TPA": {Code: "TPA", Name: "Tampa International", City: "Tampa, FL", Latitude: 27.9756, Longitude: -82.5333, Timezone: "America/New_York", Metro: ""},
+	"TUL": {Code: "TUL", Name: "Tulsa International", City: "Tulsa, OK", Latitude: 36.1984, Longitude: -95.8881, Timezone: "America/Chicago", Metro: ""},
+	"TUS": {Code: "TUS", Name: "Tucson International", City: "Tucson, AZ", Latitude: 32.1161, Longitude: -110.9410, Timezone: "America/Phoenix", Metro: ""},
+	"VPS": {Code: "VPS", Name: "Destin-Fort Walton Beach", City: "Valparaiso, FL", Latitude: 30.4832, Longitude: -86.5254, Timezone: "America/Chicago", Metro: ""},
+}
+
+// Airport with a code, or false if it isn't a Southwest airport.
+func Lookup(code string) (*Airport, bool) {
+	airport, ok := registry[strings.ToUpper(code)]
+	return airport, ok
+}
+
+// Codes of the airports in a metro area group, sorted, or false if there is no such group.
+func Metro(name string) ([]string, bool) {
+	name = strings.ToUpper(strings.TrimPrefix(name, MetroPrefix))
+	codes := make([]string, 0)
+	for code, airport := range registry {
+		if airport.Metro != "" && airport.Metro == name {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes, len(codes) > 0
+}
+
+// Expand metro area groups like "@BAY" into their airports, and check every code is known.
+// Codes are uppercased, and duplicates dropped.
+func Expand(codes []string) ([]string, error) {
+	expanded := make([]string, 0, len(codes))
+	seen := make(map[string]bool)
+	add := func(code string) {
+		if !seen[code] {
+			seen[code] = true
+			expanded = append(expanded, code)
+		}
+	}
+
+	for _, code := range codes {
+		if strings.HasPrefix(code, MetroPrefix) {
+			metroCodes, ok := Metro(code)
+			if !ok {
+				return nil, fmt.Errorf("Unknown metro area %q", code)
+			}
+			for _, metroCode := range metroCodes {
+				add(metroCode)
+			}
+			continue
+		}
+
+		airport, ok := Lookup(code)
+		if !ok {
+			return nil, fmt.Errorf("Unknown airport %q", code)
+		}
+		add(airport.Code)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,46 @@
+package airports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Embed the time zone database so zones load without system tzdata
+	_ "time/tzdata"
+)
+
+// Loaded once, so times in the same zone share a *time.Location and compare equal with ==.
+var locations = loadLocations()
+
+func loadLocations() map[string]*time.Location {
+	locations := make(map[string]*time.Location, len(registry))
+	for code, airport := range registry {
+		location, err := time.LoadLocation(airport.Timezone)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid time zone %q for %s: %v", airport.Timezone, code, err))
+		}
+		locations[code] = location
+	}
+	return locations
+}
+
+// Time zone of an airport, or false if the airport is unknown.
+func Location(code string) (*time.Location, bool) {
+	location, ok := locations[strings.ToUpper(code)]
+	return location, ok
+}
+
+// Same wall clock time as t, in the airport's time zone.
+// t's own zone is ignored. If the airport is unknown, the wall clock time is returned in UTC.
+func InLocalTime(t time.Time, code string) time.Time {
+	location, ok := Location(code)
+	if !ok {
+		location = time.UTC
+	}
+	return WallClock(t, location)
+}
+
+// Same wall clock time as t, in location.
+func WallClock(t time.Time, location *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+// Cheapest fare on one day of a flexible date search.
+type CalendarDay struct {
+	Date time.Time
+
+	// Cheapest per person fare of the day's flights, or nil if none are available.
+	Cheapest *model.Fare
+
+	// Change in the cheapest fare since the last update.
+	Update FlightStateChange
+}
+
+// Build a search's calendar from its latest flights, comparing each day to the previous calendar.
+func newCalendar(search *FlightSearch, flights FlightStates, previous []*CalendarDay) []*CalendarDay {
+	cheapest := make(map[string]*model.Fare)
+	for _, flight := range availableFlights(flights) {
+		day := flight.DepartureLocalTime.Format(dateLayout)
+		if fare := flight.CheapestAvailableFare(); fare.Compare(cheapest[day]) < 0 {
+			cheapest[day] = fare
+		}
+	}
+
+	previousByDay := make(map[string]*CalendarDay, len(previous))
+	for _, calendarDay := range previous {
+		previousByDay[calendarDay.Date.Format(dateLayout)] = calendarDay
+	}
+
+	calendar := make([]*CalendarDay, 0)
+	for _, day := range search.DepartureDays() {
+		key := day.Format(dateLayout)
+		calendarDay := &CalendarDay{Date: day, Cheapest: cheapest[key]}
+
+		var previousFare *model.Fare
+		if previousDay, ok := previousByDay[key]; ok {
+			previousFare = previousDay.Cheapest
+		}
+		switch {
+		case previousFare == nil && calendarDay.Cheapest == nil:
+			calendarDay.Update = Unchanged
+		case previousFare == nil:
+			calendarDay.Update = Added
+		case calendarDay.Cheapest == nil:
+			calendarDay.Update = Removed
+		case calendarDay.Cheapest.Compare(previousFare) < 0:
+			calendarDay.Update = FareDecrease
+		case calendarDay.Cheapest.Compare(previousFare) > 0:
+			calendarDay.Update = FareIncrease
+		}
+		calendar = append(calendar, calendarDay)
+	}
+	return calendar
+}
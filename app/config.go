@@ -2,19 +2,78 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/airports"
+	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+const (
+	currencyDollars = "dollars"
+	currencyPoints  = "points"
 )
 
+// Most days a flexible date search can span, to bound the requests made per update.
+const maxFlexibleDays = 31
+
+const dateLayout = "2006-01-02"
+
+// Most passengers Southwest allows on one booking, adults and seniors together.
+const maxPassengers = 8
+
+// Config names of fare classes
+var fareClassNames = map[string]model.FareClass{
+	"wanna_get_away":      model.WannaGetAway,
+	"wanna_get_away_plus": model.WannaGetAwayPlus,
+	"anytime":             model.Anytime,
+	"business_select":     model.BusinessSelect,
+}
+
 // Config JSON representation of flight search
 type FlightSearch struct {
-	OriginAirports      []string  `json:"origin_airports"`
-	DestinationAirports []string  `json:"destination_airports"`
-	MinDepartureTime    time.Time `json:"min_departure_time"`
-	MaxArrivalTime      time.Time `json:"max_arrival_time"`
-	MaxFareCents        *uint32   `json:"max_fare_cents"`
-	MaxNumberStops      *uint8    `json:"max_number_stops"`
-	Note                *string   `json:"note"`
+	OriginAirports      []string   `json:"origin_airports"`      // codes or metro areas, e.g. ["SFO", "@LA"]
+	DestinationAirports []string   `json:"destination_airports"` // expanded to codes on load
+	MinDepartureTime    time.Time  `json:"min_departure_time"`
+	MaxArrivalTime      time.Time  `json:"max_arrival_time"`
+	MaxDepartureTime    *time.Time `json:"max_departure_time"` // optional, on min_departure_time's date
+	MinArrivalTime      *time.Time `json:"min_arrival_time"`   // optional
+	MaxFareCents        *uint32    `json:"max_fare_cents"`
+	MaxFarePoints       *uint32    `json:"max_fare_points"`
+	MaxNumberStops      *uint8     `json:"max_number_stops"`
+	AdultPassengers     *uint8     `json:"adult_passengers"`
+	SeniorPassengers    *uint8     `json:"senior_passengers"`
+	Currency            *string    `json:"currency"` // "dollars" (default) or "points"
+	PromoCode           *string    `json:"promo_code"`
+	FareClasses         []string   `json:"fare_classes"` // e.g. ["anytime", "business_select"], defaults to all
+	Note                *string    `json:"note"`
+
+	// Optional limits on travel time and connections. Durations are e.g. "5h30m".
+	MaxDuration                *string  `json:"max_duration"`   // departure to arrival
+	MaxLayover                 *string  `json:"max_layover"`    // longest single layover
+	MinConnection              *string  `json:"min_connection"` // shortest single layover
+	ExcludedConnectionAirports []string `json:"excluded_connection_airports"`
+
+	// Optional expression of registered filters, applied with the limits above.
+	Filters *client.FilterSpec `json:"filters"`
+
+	// Optional last date to depart, e.g. "2016-03-17", making this a flexible date search.
+	// Each day from min_departure_time's date up to and including it is searched with the
+	// same departure and arrival times of day as min_departure_time and max_arrival_time.
+	LastDepartureDate *string `json:"last_departure_date"`
+
+	// Optional, repeats the search every week instead of searching only min_departure_time's date.
+	Weekly *WeeklySchedule `json:"weekly"`
+
+	// Optional return leg, making this a round trip search. Its airports default to the
+	// reverse of this search's, and it always uses this search's passengers, currency and promo code.
+	Return            *FlightSearch `json:"return"`
+	MinStay           *string       `json:"min_stay"` // e.g. "24h", from outbound arrival to return departure
+	MaxStay           *string       `json:"max_stay"`
+	MaxTripFareCents  *uint32       `json:"max_trip_fare_cents"` // per person, both legs
+	MaxTripFarePoints *uint32       `json:"max_trip_fare_points"`
 }
 
 func FlightSearchesFromJson(data []byte) ([]*FlightSearch, error) {
@@ -22,6 +81,16 @@ func FlightSearchesFromJson(data []byte) ([]*FlightSearch, error) {
 	if err := json.Unmarshal(data, &results); err != nil {
 		return nil, err
 	}
+	for i, search := range results {
+		if err := search.expandAirports(); err != nil {
+			return nil, fmt.Errorf("Invalid search %d: %v", i, err)
+		}
+		search.useWallClockTimes()
+		search.applyReturnDefaults()
+		if err := search.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid search %d: %v", i, err)
+		}
+	}
 	return results, nil
 }
 
@@ -33,6 +102,251 @@ func FlightSearchesFromFile(filename string) ([]*FlightSearch, error) {
 	return FlightSearchesFromJson(data)
 }
 
+// Check settings that JSON decoding can't.
+func (f *FlightSearch) Validate() error {
+	codes := append(append([]string{}, f.OriginAirports...), f.DestinationAirports...)
+	for _, code := range append(codes, f.ExcludedConnectionAirports...) {
+		if _, ok := airports.Lookup(code); !ok {
+			return fmt.Errorf("unknown airport %q", code)
+		}
+	}
+	if f.Currency != nil && *f.Currency != currencyDollars && *f.Currency != currencyPoints {
+		return fmt.Errorf("currency must be %q or %q, not %q", currencyDollars, currencyPoints, *f.Currency)
+	}
+	if partySize := f.FareQuery().PartySize(); partySize > maxPassengers {
+		return fmt.Errorf("%d passengers is more than the %d allowed per booking", partySize, maxPassengers)
+	}
+	if f.MaxDepartureTime != nil {
+		if f.MaxDepartureTime.Before(f.MinDepartureTime) {
+			return fmt.Errorf("max_departure_time is before min_departure_time")
+		}
+		if !date(*f.MaxDepartureTime).Equal(date(f.MinDepartureTime)) {
+			return fmt.Errorf("max_departure_time must be on the same date as min_departure_time")
+		}
+	}
+	if f.MinArrivalTime != nil && f.MinArrivalTime.After(f.MaxArrivalTime) {
+		return fmt.Errorf("min_arrival_time is after max_arrival_time")
+	}
+	if f.MaxFareCents != nil && f.PricedInPoints() {
+		return fmt.Errorf("max_fare_cents can't be used with points currency, use max_fare_points")
+	}
+	if f.MaxFarePoints != nil && !f.PricedInPoints() {
+		return fmt.Errorf("max_fare_points requires points currency")
+	}
+	for _, name := range f.FareClasses {
+		if _, ok := fareClassNames[name]; !ok {
+			return fmt.Errorf("unknown fare class %q", name)
+		}
+	}
+	for _, limit := range []*string{f.MaxDuration, f.MaxLayover, f.MinConnection} {
+		if limit != nil {
+			if _, err := time.ParseDuration(*limit); err != nil {
+				return fmt.Errorf("invalid duration %q: %v", *limit, err)
+			}
+		}
+	}
+	if maxLayover, minConnection := parseDuration(f.MaxLayover), parseDuration(f.MinConnection); f.MaxLayover != nil && minConnection > maxLayover {
+		return fmt.Errorf("min_connection is longer than max_layover")
+	}
+	if f.Filters != nil {
+		if _, err := f.Filters.Build(); err != nil {
+			return fmt.Errorf("filters: %v", err)
+		}
+	}
+	if f.LastDepartureDate != nil {
+		lastDay, err := time.Parse(dateLayout, *f.LastDepartureDate)
+		if err != nil {
+			return fmt.Errorf("invalid last_departure_date %q: %v", *f.LastDepartureDate, err)
+		}
+		if lastDay.Before(date(f.MinDepartureTime)) {
+			return fmt.Errorf("last_departure_date is before min_departure_time")
+		}
+		if days := len(f.DepartureDays()); days > maxFlexibleDays {
+			return fmt.Errorf("last_departure_date spans %d days, at most %d are allowed", days, maxFlexibleDays)
+		}
+		if f.Return != nil {
+			return fmt.Errorf("last_departure_date can't be used with a return search")
+		}
+	}
+	if f.Weekly != nil {
+		if err := f.Weekly.validate(f); err != nil {
+			return fmt.Errorf("weekly: %v", err)
+		}
+	}
+
+	if f.Return == nil {
+		if f.MinStay != nil || f.MaxStay != nil || f.MaxTripFareCents != nil || f.MaxTripFarePoints != nil {
+			return fmt.Errorf("min_stay, max_stay, max_trip_fare_cents and max_trip_fare_points require a return search")
+		}
+		return nil
+	}
+
+	if f.Return.Return != nil {
+		return fmt.Errorf("return search can't have its own return")
+	}
+	if f.Return.Weekly != nil {
+		return fmt.Errorf("return search can't be weekly, it repeats with the outbound search")
+	}
+	if err := f.Return.Validate(); err != nil {
+		return fmt.Errorf("return: %v", err)
+	}
+	if f.Return.MinDepartureTime.Before(f.MinDepartureTime) {
+		return fmt.Errorf("return min_departure_time is before outbound min_departure_time")
+	}
+	if f.MaxTripFareCents != nil && f.PricedInPoints() {
+		return fmt.Errorf("max_trip_fare_cents can't be used with points currency, use max_trip_fare_points")
+	}
+	if f.MaxTripFarePoints != nil && !f.PricedInPoints() {
+		return fmt.Errorf("max_trip_fare_points requires points currency")
+	}
+	for _, stay := range []*string{f.MinStay, f.MaxStay} {
+		if stay != nil {
+			if _, err := time.ParseDuration(*stay); err != nil {
+				return fmt.Errorf("invalid stay %q: %v", *stay, err)
+			}
+		}
+	}
+	if minStay, maxStay := f.StayLimits(); maxStay > 0 && minStay > maxStay {
+		return fmt.Errorf("min_stay is longer than max_stay")
+	}
+	return nil
+}
+
+// Replace metro areas like "@BAY" with their airports, rejecting unknown codes.
+func (f *FlightSearch) expandAirports() error {
+	var err error
+	if f.OriginAirports, err = airports.Expand(f.OriginAirports); err != nil {
+		return fmt.Errorf("origin_airports: %v", err)
+	}
+	if f.DestinationAirports, err = airports.Expand(f.DestinationAirports); err != nil {
+		return fmt.Errorf("destination_airports: %v", err)
+	}
+	if f.ExcludedConnectionAirports, err = airports.Expand(f.ExcludedConnectionAirports); err != nil {
+		return fmt.Errorf("excluded_connection_airports: %v", err)
+	}
+	if f.Return != nil {
+		if err := f.Return.expandAirports(); err != nil {
+			return fmt.Errorf("return: %v", err)
+		}
+	}
+	return nil
+}
+
+// Drop the time zones of configured times, keeping their wall clock times in UTC.
+// Searches compare them to local times at each flight's own airports.
+func (f *FlightSearch) useWallClockTimes() {
+	f.MinDepartureTime = airports.WallClock(f.MinDepartureTime, time.UTC)
+	f.MaxArrivalTime = airports.WallClock(f.MaxArrivalTime, time.UTC)
+	if f.MaxDepartureTime != nil {
+		maxDepartureTime := airports.WallClock(*f.MaxDepartureTime, time.UTC)
+		f.MaxDepartureTime = &maxDepartureTime
+	}
+	if f.MinArrivalTime != nil {
+		minArrivalTime := airports.WallClock(*f.MinArrivalTime, time.UTC)
+		f.MinArrivalTime = &minArrivalTime
+	}
+	if f.Return != nil {
+		f.Return.useWallClockTimes()
+	}
+}
+
+// Default the return leg's airports and fare classes, and copy this search's passengers, currency and promo code to it.
+func (f *FlightSearch) applyReturnDefaults() {
+	if f.Return == nil {
+		return
+	}
+	if len(f.Return.OriginAirports) == 0 {
+		f.Return.OriginAirports = f.DestinationAirports
+	}
+	if len(f.Return.DestinationAirports) == 0 {
+		f.Return.DestinationAirports = f.OriginAirports
+	}
+	if len(f.Return.FareClasses) == 0 {
+		f.Return.FareClasses = f.FareClasses
+	}
+	f.Return.AdultPassengers = f.AdultPassengers
+	f.Return.SeniorPassengers = f.SeniorPassengers
+	f.Return.Currency = f.Currency
+	f.Return.PromoCode = f.PromoCode
+}
+
+// Minimum and maximum time between outbound arrival and return departure.
+// A maximum of 0 means no limit.
+func (f *FlightSearch) StayLimits() (minStay time.Duration, maxStay time.Duration) {
+	return parseDuration(f.MinStay), parseDuration(f.MaxStay)
+}
+
+// Duration of a validated config value, or 0 if unset.
+func parseDuration(value *string) time.Duration {
+	if value == nil {
+		return 0
+	}
+	d, _ := time.ParseDuration(*value)
+	return d
+}
+
+func (f *FlightSearch) Flexible() bool {
+	return f.LastDepartureDate != nil
+}
+
+// Dates searched, from min_departure_time's date through last_departure_date.
+func (f *FlightSearch) DepartureDays() []time.Time {
+	first := date(f.MinDepartureTime)
+	last := f.lastDepartureDay()
+
+	days := make([]time.Time, 0)
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// Departure and arrival limits on one of the search's days. The window keeps the same
+// times of day, and the same length, as min_departure_time to max_arrival_time.
+func (f *FlightSearch) WindowOn(day time.Time) (minDepartureTime time.Time, maxArrivalTime time.Time) {
+	offset := f.dayOffset(day)
+	return f.MinDepartureTime.Add(offset), f.MaxArrivalTime.Add(offset)
+}
+
+// Time from the first day of the search to day, to move configured times to that day.
+func (f *FlightSearch) dayOffset(day time.Time) time.Duration {
+	return day.Sub(date(f.MinDepartureTime))
+}
+
+func (f *FlightSearch) lastDepartureDay() time.Time {
+	if f.LastDepartureDate != nil {
+		if lastDay, err := time.Parse(dateLayout, *f.LastDepartureDate); err == nil {
+			return lastDay
+		}
+	}
+	return date(f.MinDepartureTime)
+}
+
+func (f *FlightSearch) PricedInPoints() bool {
+	return f.Currency != nil && *f.Currency == currencyPoints
+}
+
+// Passengers, currency, promo code and fare classes to search for. Defaults to one adult, in dollars, any class.
+func (f *FlightSearch) FareQuery() client.FareQuery {
+	query := client.FareQuery{}
+	if f.PricedInPoints() {
+		query.Currency = model.Points
+	}
+	if f.PromoCode != nil {
+		query.PromoCode = *f.PromoCode
+	}
+	if f.AdultPassengers != nil {
+		query.AdultPassengers = *f.AdultPassengers
+	}
+	if f.SeniorPassengers != nil {
+		query.SeniorPassengers = *f.SeniorPassengers
+	}
+	for _, name := range f.FareClasses {
+		query.FareClasses = append(query.FareClasses, fareClassNames[name])
+	}
+	return query
+}
+
 func (f *FlightSearch) String() string {
 	b, err := json.Marshal(f)
 	if err != nil {
@@ -40,3 +354,96 @@ func (f *FlightSearch) String() string {
 	}
 	return string(b)
 }
+
+// Long enough for the searches in one update cycle to share responses,
+// short enough that the next hourly cycle sees fresh fares.
+const DefaultCacheTtl = 5 * time.Minute
+
+// Config JSON representation of Southwest API client settings.
+// All fields are optional; unset fields use the client defaults.
+type ClientConfig struct {
+	BaseUrl        string `json:"base_url"`
+	ApiKey         string `json:"api_key"`
+	UserAgent      string `json:"user_agent"`
+	RequestTimeout string `json:"request_timeout"` // e.g. "30s"
+	HttpProxy      string `json:"http_proxy"`
+	MaxAttempts    int    `json:"max_attempts"`    // 1 disables retries
+	InitialBackoff string `json:"initial_backoff"` // e.g. "1s"
+	MaxBackoff     string `json:"max_backoff"`     // e.g. "30s"
+
+	RequestsPerSecond float64 `json:"requests_per_second"` // negative disables rate limiting
+	Burst             int     `json:"burst"`
+
+	MaxConcurrency int `json:"max_concurrency"`
+
+	CacheTtl string `json:"cache_ttl"` // e.g. "5m". Defaults to DefaultCacheTtl, "0s" disables caching
+	CacheDir string `json:"cache_dir"` // caches on disk instead of in memory if set
+
+	SchemaBaselineFile string `json:"schema_baseline_file"` // keeps the expected response shape across restarts
+}
+
+func ClientConfigFromFile(filename string) (*ClientConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ClientConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *ClientConfig) Options() (client.Options, error) {
+	opts := client.Options{
+		BaseUrl:   c.BaseUrl,
+		ApiKey:    c.ApiKey,
+		UserAgent: c.UserAgent,
+		ProxyUrl:  c.HttpProxy,
+		Retry:     client.DefaultRetryPolicy,
+
+		RequestsPerSecond: c.RequestsPerSecond,
+		Burst:             c.Burst,
+
+		MaxConcurrency: c.MaxConcurrency,
+
+		SchemaBaselineFile: c.SchemaBaselineFile,
+	}
+
+	if c.MaxAttempts != 0 {
+		opts.Retry.MaxAttempts = c.MaxAttempts
+	}
+
+	cacheTtl := DefaultCacheTtl
+	for _, d := range []struct {
+		value string
+		dest  *time.Duration
+	}{
+		{c.RequestTimeout, &opts.RequestTimeout},
+		{c.InitialBackoff, &opts.Retry.InitialBackoff},
+		{c.MaxBackoff, &opts.Retry.MaxBackoff},
+		{c.CacheTtl, &cacheTtl},
+	} {
+		if d.value != "" {
+			parsed, err := time.ParseDuration(d.value)
+			if err != nil {
+				return opts, err
+			}
+			*d.dest = parsed
+		}
+	}
+
+	if cacheTtl > 0 {
+		if c.CacheDir != "" {
+			cache, err := client.NewDiskCache(c.CacheDir, cacheTtl)
+			if err != nil {
+				return opts, err
+			}
+			opts.Cache = cache
+		} else {
+			opts.Cache = client.NewMemoryCache(cacheTtl)
+		}
+	}
+	return opts, nil
+}
@@ -1,36 +1,116 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/alecholmes/southwest_flight_watcher/client"
 	"github.com/alecholmes/southwest_flight_watcher/model"
 )
 
+// Source of flights for a search. client.Client is the Southwest implementation.
+// Providers may return flights along with a *client.PartialError if some routes failed.
+type FlightProvider interface {
+	SearchFlights(
+		ctx context.Context,
+		originAirports []string,
+		destinationAirports []string,
+		minLocalDepartureTime time.Time,
+		maxLocalArrivalTime time.Time,
+		query client.FareQuery,
+		filters []client.FlightFilter) ([]*model.Flight, error)
+}
+
+var _ FlightProvider = &client.Client{}
+
+// Optionally implemented by a FlightProvider that checks responses for changes in Southwest's API.
+type SchemaDriftSource interface {
+	TakeSchemaDrift() *client.SchemaDrift
+}
+
+var _ SchemaDriftSource = &client.Client{}
+
 type FlightFetcher struct {
-	swClient *client.Client
+	provider FlightProvider
 }
 
-func NewFlightFetcher(swClient *client.Client) *FlightFetcher {
-	return &FlightFetcher{swClient}
+func NewFlightFetcher(provider FlightProvider) *FlightFetcher {
+	return &FlightFetcher{provider}
 }
 
-// Fetch all flights that match a given search
-func (f *FlightFetcher) Fetch(search *FlightSearch) ([]*model.Flight, error) {
+// Fetch all flights that match a given search, on each of its days that hasn't passed.
+// Like FlightProvider, may return flights along with a *client.PartialError.
+func (f *FlightFetcher) Fetch(ctx context.Context, search *FlightSearch) ([]*model.Flight, error) {
+	today := localDate(time.Now())
+
+	flights := make([]*model.Flight, 0)
+	failures := &client.PartialError{}
+	for _, day := range search.DepartureDays() {
+		if today.After(day) {
+			continue
+		}
+
+		dayFlights, err := f.fetchDay(ctx, search, day)
+		var partialErr *client.PartialError
+		if errors.As(err, &partialErr) {
+			failures.Failures = append(failures.Failures, partialErr.Failures...)
+		} else if err != nil {
+			return nil, err
+		}
+		flights = append(flights, dayFlights...)
+	}
+
+	if len(failures.Failures) > 0 {
+		return flights, failures
+	}
+	return flights, nil
+}
+
+func (f *FlightFetcher) fetchDay(ctx context.Context, search *FlightSearch, day time.Time) ([]*model.Flight, error) {
 	filters := make([]client.FlightFilter, 0)
 	if search.MaxFareCents != nil {
 		filters = append(filters, &client.MaxAvailableFareFilter{*search.MaxFareCents})
 	}
+	if search.MaxFarePoints != nil {
+		filters = append(filters, &client.MaxAvailableFarePointsFilter{*search.MaxFarePoints})
+	}
 	if search.MaxNumberStops != nil {
 		filters = append(filters, &client.MaxStopsFilter{int(*search.MaxNumberStops)})
 	}
+	if search.MaxDuration != nil {
+		filters = append(filters, &client.MaxDurationFilter{parseDuration(search.MaxDuration)})
+	}
+	if search.MaxLayover != nil {
+		filters = append(filters, &client.MaxLayoverFilter{parseDuration(search.MaxLayover)})
+	}
+	if search.MinConnection != nil {
+		filters = append(filters, &client.MinConnectionFilter{parseDuration(search.MinConnection)})
+	}
+	if len(search.ExcludedConnectionAirports) > 0 {
+		filters = append(filters, &client.ExcludedConnectionsFilter{search.ExcludedConnectionAirports})
+	}
+	if search.MaxDepartureTime != nil {
+		filters = append(filters, &client.DepartBeforeFilter{search.MaxDepartureTime.Add(search.dayOffset(day))})
+	}
+	if search.MinArrivalTime != nil {
+		filters = append(filters, &client.ArriveAfterFilter{search.MinArrivalTime.Add(search.dayOffset(day))})
+	}
+	if search.Filters != nil {
+		filter, err := search.Filters.Build()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
 
-	flights, err := f.swClient.SearchFlights(
+	minDepartureTime, maxArrivalTime := search.WindowOn(day)
+	return f.provider.SearchFlights(
+		ctx,
 		search.OriginAirports,
 		search.DestinationAirports,
-		search.MinDepartureTime,
-		search.MaxArrivalTime,
+		minDepartureTime,
+		maxArrivalTime,
+		search.FareQuery(),
 		filters)
-	if err != nil {
-		return nil, err
-	}
-	return flights, nil
 }
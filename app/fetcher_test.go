@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/client/fake_api"
+)
+
+func TestFlightFetcherPromoCode(t *testing.T) {
+	server := fake_api.NewServer()
+	defer server.Close()
+
+	day := date(time.Now()).AddDate(0, 0, 30)
+	route := fake_api.Route{Origin: "SFO", Destination: "BUR", DepartureDate: day.Format(dateLayout)}
+	promoRoute := route
+	promoRoute.PromoCode = "SALE"
+	promoRoute.Currency = "Dollars"
+	promoRoute.AdultPassengers = 1
+	regular := fake_api.FixtureFare{FareType: "WGA", Cents: 9900, SeatsAvailable: 3}
+	promo := fake_api.FixtureFare{FareType: "WGA", Cents: 7900, SeatsAvailable: 3}
+
+	server.Script(route, fake_api.Respond(fake_api.NewFixture().Flight(nonstop(day, "SFO", "100", 8), regular).Response()))
+	server.Script(promoRoute, fake_api.Respond(fake_api.NewFixture().Flight(nonstop(day, "SFO", "100", 8), promo).Response()))
+
+	fetcher := NewFlightFetcher(newFakeClient(t, server, client.RetryPolicy{MaxAttempts: 1}))
+	flights, err := fetcher.Fetch(context.Background(), searchesOn(t, day, `, "promo_code": "SALE"`)[0])
+	if err == nil {
+		// OAK-BUR isn't scripted
+		t.Fatal("expected a route failure")
+	}
+	if len(flights) != 1 {
+		t.Fatalf("expected 1 flight, got %d", len(flights))
+	}
+	if cents := flights[0].CheapestAvailableFare().Cents; cents != 7900 {
+		t.Errorf("expected promo fare 7900, got %d", cents)
+	}
+	if cents := flights[0].CheapestAvailableRegularFare().Cents; cents != 9900 {
+		t.Errorf("expected regular fare 9900, got %d", cents)
+	}
+	if server.Calls(route) != 1 || server.Calls(promoRoute) != 1 {
+		t.Errorf("expected one regular and one promo request, got %d and %d", server.Calls(route), server.Calls(promoRoute))
+	}
+}
+
+func TestFlightFetcherRetryAfter(t *testing.T) {
+	day := date(time.Now()).AddDate(0, 0, 30)
+	route := fake_api.Route{Origin: "SFO", Destination: "BUR", DepartureDate: day.Format(dateLayout)}
+	fare := fake_api.FixtureFare{FareType: "WGA", Cents: 9900, SeatsAvailable: 3}
+	// Retry-After is honored beyond the max backoff, as long as it ends before the deadline
+	retry := client.RetryPolicy{MaxAttempts: 3, MaxBackoff: time.Millisecond}
+
+	for _, test := range []struct {
+		name       string
+		retryAfter string
+		flights    int
+		calls      int
+	}{
+		{name: "waits", retryAfter: "1", flights: 1, calls: 2},
+		{name: "past deadline", retryAfter: "3600", flights: 0, calls: 1},
+	} {
+		server := fake_api.NewServer()
+		server.Script(route,
+			fake_api.Step{Status: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {test.retryAfter}}},
+			fake_api.Respond(fake_api.NewFixture().Flight(nonstop(day, "SFO", "100", 8), fare).Response()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		fetcher := NewFlightFetcher(newFakeClient(t, server, retry))
+		flights, err := fetcher.Fetch(ctx, searchesOn(t, day, "")[0])
+		cancel()
+		server.Close()
+
+		// OAK-BUR isn't scripted, so always fails
+		var partialErr *client.PartialError
+		if !errors.As(err, &partialErr) || len(partialErr.Failures) != 2-test.flights {
+			t.Errorf("%s: expected %d route failures, got %v", test.name, 2-test.flights, err)
+		}
+		if len(flights) != test.flights || server.Calls(route) != test.calls {
+			t.Errorf("%s: expected %d flights after %d calls, got %d flights after %d calls",
+				test.name, test.flights, test.calls, len(flights), server.Calls(route))
+		}
+	}
+}
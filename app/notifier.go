@@ -1,32 +1,77 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/model"
 )
 
 type SearchUpdateNotifier interface {
-	Notify(searchStates FlightSearchStates) error
+	Notify(ctx context.Context, searchStates FlightSearchStates) error
+}
+
+// Optionally implemented by notifiers to report changes in the shape of Southwest responses,
+// which usually mean Southwest changed its API and results may be incomplete.
+type SchemaDriftNotifier interface {
+	NotifySchemaDrift(ctx context.Context, drift *client.SchemaDrift) error
 }
 
 type StdoutNotifier struct{}
 
 var _ SearchUpdateNotifier = &StdoutNotifier{}
+var _ SchemaDriftNotifier = &StdoutNotifier{}
 
-func (s *StdoutNotifier) Notify(searchStates FlightSearchStates) error {
-	for search, updates := range searchStates {
+func (s *StdoutNotifier) Notify(ctx context.Context, searchStates FlightSearchStates) error {
+	for search, state := range searchStates {
 		fmt.Println(search)
-		for _, update := range updates {
-			fmt.Printf("  (%s) %v %v -> %v %v: $%v\n",
+		if state.Incomplete() {
+			fmt.Printf("  Incomplete, failed routes: %s\n", routesString(state.AllFailedRoutes()))
+		}
+		if state.RoundTrip != nil {
+			for i, roundTrip := range state.RoundTrip.Cheapest {
+				update := Unchanged
+				if i == 0 {
+					update = state.RoundTrip.Update
+				}
+				fmt.Printf("  (%s) %v %v -> %v %v%s, %v %v -> %v %v%s: %v\n",
+					s.flightStateChangeString(update),
+					roundTrip.Outbound.OriginAirport, s.timeString(roundTrip.Outbound.DepartureLocalTime),
+					roundTrip.Outbound.DestinationAirport, s.timeString(roundTrip.Outbound.ArrivalLocalTime),
+					s.detailsString(roundTrip.Outbound),
+					roundTrip.Return.OriginAirport, s.timeString(roundTrip.Return.DepartureLocalTime),
+					roundTrip.Return.DestinationAirport, s.timeString(roundTrip.Return.ArrivalLocalTime),
+					s.detailsString(roundTrip.Return),
+					priceString(roundTrip.PartyAmount(), roundTrip.Currency()))
+			}
+			continue
+		}
+		if len(state.Calendar) > 0 {
+			fmt.Printf("  Calendar: %s\n", s.calendarString(state.Calendar))
+		}
+		for _, update := range state.Flights {
+			fmt.Printf("  (%s) %v %v -> %v %v%s: %v\n",
 				s.flightStateChangeString(update.Update),
 				update.Flight.OriginAirport, s.timeString(update.Flight.DepartureLocalTime),
 				update.Flight.DestinationAirport, s.timeString(update.Flight.ArrivalLocalTime),
-				float64(update.Flight.CheapestAvailableFare().Cents)/100)
+				s.detailsString(update.Flight),
+				s.costString(update.Flight))
 		}
 	}
 	return nil
 }
 
+func (s *StdoutNotifier) NotifySchemaDrift(ctx context.Context, drift *client.SchemaDrift) error {
+	fmt.Printf("Southwest response shape changed, fingerprint now %s:\n", drift.Fingerprint)
+	for _, entry := range drift.Entries {
+		fmt.Printf("  %s\n", entry)
+	}
+	return nil
+}
+
 func (s *StdoutNotifier) flightStateChangeString(f FlightStateChange) string {
 	switch f {
 	case Unchanged:
@@ -44,17 +89,73 @@ func (s *StdoutNotifier) flightStateChangeString(f FlightStateChange) string {
 	}
 }
 
+// Travel time, flight numbers and layovers, e.g. " (3h 10m) #1234, #567 via DEN 1h 5m"
+func (s *StdoutNotifier) detailsString(flight *model.Flight) string {
+	details := fmt.Sprintf(" (%s)", durationString(flight.Duration()))
+	if numbers := flightNumbersString(flight); numbers != "" {
+		details += " " + numbers
+	}
+	if layovers := layoversString(flight); layovers != "" {
+		details += " via " + layovers
+	}
+	return details
+}
+
+func (s *StdoutNotifier) costString(flight *model.Flight) string {
+	fare := flight.CheapestAvailableFare()
+	if fare == nil {
+		return "unavailable"
+	}
+	cost := priceString(fare.Amount(), fare.Currency)
+	if flight.PartySize > 1 {
+		cost = fmt.Sprintf("%s (%s each)", priceString(flight.CheapestAvailablePartyAmount(), fare.Currency), cost)
+	}
+	if savings := flight.PromoCodeSavings(); savings > 0 {
+		cost = fmt.Sprintf("%s (%s saves %s each)", cost, flight.PromoCode, priceString(savings, fare.Currency))
+	} else if flight.PromoCodeRejected {
+		cost = fmt.Sprintf("%s (%s rejected)", cost, flight.PromoCode)
+	}
+	return fmt.Sprintf("%s %v", cost, fare.Class)
+}
+
+// Cheapest fare of each day, e.g. "Mar 10 $ 59.00 (▼), Mar 11 unavailable (-)"
+func (s *StdoutNotifier) calendarString(calendar []*CalendarDay) string {
+	days := make([]string, len(calendar))
+	for i, day := range calendar {
+		cost := "unavailable"
+		if day.Cheapest != nil {
+			cost = priceString(day.Cheapest.Amount(), day.Cheapest.Currency)
+		}
+		days[i] = fmt.Sprintf("%s %s (%s)", day.Date.Format("Jan 2"), cost, s.flightStateChangeString(day.Update))
+	}
+	return strings.Join(days, ", ")
+}
+
 func (s *StdoutNotifier) timeString(t time.Time) string {
 	return t.Format("2006-01-02 15:04")
 }
 
 type SearchUpdateNotifierChain []SearchUpdateNotifier
 
-func (s SearchUpdateNotifierChain) Notify(searchStates FlightSearchStates) error {
+var _ SchemaDriftNotifier = SearchUpdateNotifierChain{}
+
+func (s SearchUpdateNotifierChain) Notify(ctx context.Context, searchStates FlightSearchStates) error {
 	for _, notifier := range s {
-		if err := notifier.Notify(searchStates); err != nil {
+		if err := notifier.Notify(ctx, searchStates); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// Report drift to each notifier in the chain that implements SchemaDriftNotifier.
+func (s SearchUpdateNotifierChain) NotifySchemaDrift(ctx context.Context, drift *client.SchemaDrift) error {
+	for _, notifier := range s {
+		if driftNotifier, ok := notifier.(SchemaDriftNotifier); ok {
+			if err := driftNotifier.NotifySchemaDrift(ctx, drift); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -1,7 +1,13 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
+	"html"
+	"net"
 	"net/smtp"
+
+	"github.com/alecholmes/southwest_flight_watcher/client"
 )
 
 var (
@@ -16,8 +22,9 @@ type EmailFlightsNotifier struct {
 }
 
 var _ SearchUpdateNotifier = &EmailFlightsNotifier{}
+var _ SchemaDriftNotifier = &EmailFlightsNotifier{}
 
-func (e *EmailFlightsNotifier) Notify(searchStates FlightSearchStates) error {
+func (e *EmailFlightsNotifier) Notify(ctx context.Context, searchStates FlightSearchStates) error {
 	available, improved := searchStates.OnlyAvailable()
 
 	// Only send notification if there are flights and at least one flight was updated
@@ -37,5 +44,92 @@ func (e *EmailFlightsNotifier) Notify(searchStates FlightSearchStates) error {
 		mime + "\n\n" +
 		body + "\n"
 
-	return smtp.SendMail(e.SmtpAddress, e.Auth, e.From, []string{e.To}, []byte(msg))
+	return sendMail(ctx, e.SmtpAddress, e.Auth, e.From, []string{e.To}, []byte(msg))
+}
+
+func (e *EmailFlightsNotifier) NotifySchemaDrift(ctx context.Context, drift *client.SchemaDrift) error {
+	body := "<html><body style=\"font-family: Arial, Helvetica, sans-serif\">" +
+		"<p>Southwest responses no longer match what the app expects, so search results may be incomplete. " +
+		"New in the response shape (fingerprint " + html.EscapeString(drift.Fingerprint) + "):</p><ul>"
+	for _, entry := range drift.Entries {
+		body += "<li>" + html.EscapeString(entry) + "</li>"
+	}
+	body += "</ul></body></html>"
+
+	msg := "From: " + e.From + "\n" +
+		"To: " + e.To + "\n" +
+		"Subject: Southwest API Response Changed\n" +
+		mime + "\n\n" +
+		body + "\n"
+
+	return sendMail(ctx, e.SmtpAddress, e.Auth, e.From, []string{e.To}, []byte(msg))
+}
+
+// Same as smtp.SendMail, but the dial and the whole conversation are bounded by ctx.
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Unblock any in progress read or write on cancellation
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
 }
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Most weeks a weekly search can look ahead, to bound the requests made per update.
+const maxWeeklyWeeks = 26
+
+// Config names of weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Config JSON representation of a search repeated every week.
+// The search's min_departure_time is an example occurrence on weekday. Each occurrence keeps its
+// times of day, and the days from it to last_departure_date and the return search.
+type WeeklySchedule struct {
+	Weekday string `json:"weekday"` // e.g. "thursday"
+	Weeks   int    `json:"weeks"`   // number of upcoming occurrences searched
+}
+
+func (w *WeeklySchedule) validate(search *FlightSearch) error {
+	weekday, ok := weekdayNames[strings.ToLower(w.Weekday)]
+	if !ok {
+		return fmt.Errorf("unknown weekday %q", w.Weekday)
+	}
+	if search.MinDepartureTime.Weekday() != weekday {
+		return fmt.Errorf("min_departure_time is a %v, not a %v", search.MinDepartureTime.Weekday(), weekday)
+	}
+	if w.Weeks < 1 || w.Weeks > maxWeeklyWeeks {
+		return fmt.Errorf("weeks must be from 1 to %d, not %d", maxWeeklyWeeks, w.Weeks)
+	}
+	if len(search.DepartureDays()) > 7 {
+		return fmt.Errorf("last_departure_date must be less than a week after min_departure_time")
+	}
+	return nil
+}
+
+func (f *FlightSearch) Recurring() bool {
+	return f.Weekly != nil
+}
+
+// First days of the weekly search's upcoming occurrences, starting with the earliest
+// that still has a day on or after today.
+func (f *FlightSearch) occurrenceDays(today time.Time) []time.Time {
+	first := today.AddDate(0, 0, -((int(today.Weekday()) - int(f.MinDepartureTime.Weekday()) + 7) % 7))
+	if today.After(first.Add(f.lastDepartureDay().Sub(date(f.MinDepartureTime)))) {
+		first = first.AddDate(0, 0, 7)
+	}
+
+	days := make([]time.Time, f.Weekly.Weeks)
+	for i := range days {
+		days[i] = first.AddDate(0, 0, 7*i)
+	}
+	return days
+}
+
+// Concrete search for the weekly search's occurrence starting on day.
+func (f *FlightSearch) occurrenceOn(day time.Time) *FlightSearch {
+	occurrence := f.shifted(f.dayOffset(day))
+	occurrence.Weekly = nil
+	return occurrence
+}
+
+// Copy of the search with all of its times, and its return search's, moved by offset.
+func (f *FlightSearch) shifted(offset time.Duration) *FlightSearch {
+	s := *f
+	s.MinDepartureTime = f.MinDepartureTime.Add(offset)
+	s.MaxArrivalTime = f.MaxArrivalTime.Add(offset)
+	if f.MaxDepartureTime != nil {
+		maxDepartureTime := f.MaxDepartureTime.Add(offset)
+		s.MaxDepartureTime = &maxDepartureTime
+	}
+	if f.MinArrivalTime != nil {
+		minArrivalTime := f.MinArrivalTime.Add(offset)
+		s.MinArrivalTime = &minArrivalTime
+	}
+	if f.LastDepartureDate != nil {
+		lastDepartureDate := f.lastDepartureDay().Add(offset).Format(dateLayout)
+		s.LastDepartureDate = &lastDepartureDate
+	}
+	if f.Return != nil {
+		s.Return = f.Return.shifted(offset)
+	}
+	return &s
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccurrenceDaysWestOfUTC(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+	time.Local = time.FixedZone("PDT", -7*60*60)
+
+	searches, err := FlightSearchesFromJson([]byte(`[{
+		"origin_airports": ["SFO"],
+		"destination_airports": ["BUR"],
+		"min_departure_time": "2026-10-08T17:00:00Z",
+		"max_arrival_time": "2026-10-08T21:00:00Z",
+		"weekly": {"weekday": "thursday", "weeks": 2}
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Thursday evening in California is already Friday in UTC
+	now := time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)
+	today := localDate(now)
+	if expected := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC); !today.Equal(expected) {
+		t.Fatalf("expected today to be %v, got %v", expected, today)
+	}
+
+	days := searches[0].occurrenceDays(today)
+	for i, expected := range []string{"2026-10-15", "2026-10-22"} {
+		if got := days[i].Format(dateLayout); got != expected {
+			t.Errorf("occurrence %d: expected %s, got %s", i, expected, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+// Number of cheapest round trips kept for each round trip search.
+const maxRoundTrips = 5
+
+// An outbound and return flight pair.
+type RoundTrip struct {
+	Outbound *model.Flight
+	Return   *model.Flight
+}
+
+// Time between outbound arrival and return departure.
+func (r *RoundTrip) Stay() time.Duration {
+	return r.Return.DepartureLocalTime.Sub(r.Outbound.ArrivalLocalTime)
+}
+
+// Per person price of the cheapest available fares of both legs.
+func (r *RoundTrip) Amount() uint32 {
+	return r.Outbound.CheapestAvailableFare().Amount() + r.Return.CheapestAvailableFare().Amount()
+}
+
+// Price of the cheapest available fares of both legs for the whole party.
+func (r *RoundTrip) PartyAmount() uint32 {
+	return r.Outbound.CheapestAvailablePartyAmount() + r.Return.CheapestAvailablePartyAmount()
+}
+
+func (r *RoundTrip) Currency() model.Currency {
+	return r.Outbound.CheapestAvailableFare().Currency
+}
+
+// Round trip part of a search's state.
+type RoundTripState struct {
+	ReturnFlights FlightStates
+
+	// Return routes that failed in the latest update.
+	ReturnFailedRoutes []Route
+
+	// Cheapest valid round trips, cheapest first.
+	Cheapest []*RoundTrip
+
+	// Change in the cheapest round trip price since the last update.
+	Update FlightStateChange
+}
+
+func (r *RoundTripState) Improved() bool {
+	return r.Update == Added || r.Update == FareDecrease
+}
+
+// Recompute the cheapest round trips from the latest outbound and return flights.
+func (r *RoundTripState) update(search *FlightSearch, outboundFlights FlightStates) {
+	minStay, maxStay := search.StayLimits()
+
+	roundTrips := make([]*RoundTrip, 0)
+	for _, outbound := range availableFlights(outboundFlights) {
+		for _, ret := range availableFlights(r.ReturnFlights) {
+			roundTrip := &RoundTrip{outbound, ret}
+			stay := roundTrip.Stay()
+			if stay < minStay || (maxStay > 0 && stay > maxStay) {
+				continue
+			}
+			if search.MaxTripFareCents != nil && roundTrip.Amount() > *search.MaxTripFareCents {
+				continue
+			}
+			if search.MaxTripFarePoints != nil && roundTrip.Amount() > *search.MaxTripFarePoints {
+				continue
+			}
+			roundTrips = append(roundTrips, roundTrip)
+		}
+	}
+
+	sort.Slice(roundTrips, func(i, j int) bool {
+		a, b := roundTrips[i], roundTrips[j]
+		if a.Amount() != b.Amount() {
+			return a.Amount() < b.Amount()
+		}
+		if !a.Outbound.DepartureLocalTime.Equal(b.Outbound.DepartureLocalTime) {
+			return a.Outbound.DepartureLocalTime.Before(b.Outbound.DepartureLocalTime)
+		}
+		return a.Return.DepartureLocalTime.Before(b.Return.DepartureLocalTime)
+	})
+	if len(roundTrips) > maxRoundTrips {
+		roundTrips = roundTrips[:maxRoundTrips]
+	}
+
+	previous := r.Cheapest
+	r.Cheapest = roundTrips
+
+	switch {
+	case len(previous) == 0 && len(roundTrips) == 0:
+		r.Update = Unchanged
+	case len(previous) == 0:
+		r.Update = Added
+	case len(roundTrips) == 0:
+		r.Update = Removed
+	case roundTrips[0].Amount() < previous[0].Amount():
+		r.Update = FareDecrease
+	case roundTrips[0].Amount() > previous[0].Amount():
+		r.Update = FareIncrease
+	default:
+		r.Update = Unchanged
+	}
+}
+
+// Flights that are still listed and have an available fare.
+func availableFlights(states FlightStates) []*model.Flight {
+	flights := make([]*model.Flight, 0, len(states))
+	for _, state := range states {
+		if state.Update != Removed && state.Flight.CheapestAvailableFare() != nil {
+			flights = append(flights, state.Flight)
+		}
+	}
+	return flights
+}
@@ -19,13 +19,34 @@ type FlightState struct {
 	Update FlightStateChange
 }
 
+// Origin and destination airport pair of a search on one date.
+type Route struct {
+	OriginAirport      string
+	DestinationAirport string
+	DepartureDate      string // formatted as 2006-01-02
+}
+
+func flightRoute(flight *model.Flight) Route {
+	return Route{flight.OriginAirport, flight.DestinationAirport, flight.DepartureLocalTime.Format(dateLayout)}
+}
+
+func (r Route) String() string {
+	return r.OriginAirport + "-" + r.DestinationAirport + " on " + r.DepartureDate
+}
+
 type FlightStates map[model.FlightId]FlightState
 
-func (s FlightStates) Update(flights []*model.Flight) {
+// Update states with the latest flights. Flights no longer present are marked Removed,
+// and dropped on the following update. Flights on skipped routes are left as they were,
+// since their latest results are unknown.
+func (s FlightStates) Update(flights []*model.Flight, skipped map[Route]bool) {
+	latest := make(map[model.FlightId]bool, len(flights))
 	for _, f := range flights {
+		latest[*f.Id()] = true
+
 		change := Unchanged
 		existing, ok := s[*f.Id()]
-		if !ok {
+		if !ok || existing.Update == Removed {
 			change = Added
 		} else {
 			fareChange := f.CheapestAvailableFare().Compare(existing.Flight.CheapestAvailableFare())
@@ -38,6 +59,21 @@ func (s FlightStates) Update(flights []*model.Flight) {
 
 		s[*f.Id()] = FlightState{f, change}
 	}
+
+	for id, state := range s {
+		if latest[id] {
+			continue
+		}
+		if skipped[flightRoute(state.Flight)] {
+			if state.Update != Removed {
+				s[id] = FlightState{state.Flight, Unchanged}
+			}
+		} else if state.Update == Removed {
+			delete(s, id)
+		} else {
+			s[id] = FlightState{state.Flight, Removed}
+		}
+	}
 }
 
 func (s FlightStates) OnlyAvailable() (available FlightStates, improved bool) {
@@ -53,23 +89,97 @@ func (s FlightStates) OnlyAvailable() (available FlightStates, improved bool) {
 	return
 }
 
-type FlightSearchStates map[*FlightSearch]FlightStates
+// State of one search.
+type SearchState struct {
+	Flights FlightStates
+
+	// Routes that failed in the latest update, so Flights may be incomplete.
+	FailedRoutes []Route
+
+	// Set for round trip searches.
+	RoundTrip *RoundTripState
+
+	// Set for flexible date searches.
+	Calendar []*CalendarDay
+}
+
+func (s *SearchState) Incomplete() bool {
+	return len(s.AllFailedRoutes()) > 0
+}
+
+// Failed routes of both legs.
+func (s *SearchState) AllFailedRoutes() []Route {
+	if s.RoundTrip == nil {
+		return s.FailedRoutes
+	}
+	return append(append([]Route{}, s.FailedRoutes...), s.RoundTrip.ReturnFailedRoutes...)
+}
+
+type FlightSearchStates map[*FlightSearch]*SearchState
 
 func NewFlightSearchStates() FlightSearchStates {
 	return make(FlightSearchStates)
 }
 
-func (f FlightSearchStates) Update(search *FlightSearch, flights []*model.Flight) {
-	f.getFlightStates(search).Update(flights)
+// Update a search with its latest flights. Flights on failed routes are kept as they were.
+func (f FlightSearchStates) Update(search *FlightSearch, flights []*model.Flight, failedRoutes []Route) {
+	state := f.getSearchState(search)
+
+	skipped := make(map[Route]bool, len(failedRoutes))
+	for _, route := range failedRoutes {
+		skipped[route] = true
+	}
+	state.Flights.Update(flights, skipped)
+	state.FailedRoutes = failedRoutes
+
+	if search.Flexible() {
+		state.Calendar = newCalendar(search, state.Flights, state.Calendar)
+	}
+}
+
+// Update a round trip search with its latest return flights, after updating its outbound flights.
+func (f FlightSearchStates) UpdateRoundTrip(search *FlightSearch, returnFlights []*model.Flight, failedRoutes []Route) {
+	state := f.getSearchState(search)
+	if state.RoundTrip == nil {
+		state.RoundTrip = &RoundTripState{ReturnFlights: make(FlightStates)}
+	}
+
+	skipped := make(map[Route]bool, len(failedRoutes))
+	for _, route := range failedRoutes {
+		skipped[route] = true
+	}
+	state.RoundTrip.ReturnFlights.Update(returnFlights, skipped)
+	state.RoundTrip.ReturnFailedRoutes = failedRoutes
+	state.RoundTrip.update(search, state.Flights)
 }
 
 func (f FlightSearchStates) OnlyAvailable() (available FlightSearchStates, improved bool) {
 	available = make(FlightSearchStates)
 
-	for search, states := range f {
-		availableStates, statesImproved := states.OnlyAvailable()
+	for search, state := range f {
+		availableStates, statesImproved := state.Flights.OnlyAvailable()
+
+		// Round trips only count as available or improved as a whole, not by leg
+		if state.RoundTrip != nil {
+			if len(state.RoundTrip.Cheapest) > 0 {
+				available[search] = &SearchState{
+					Flights:      availableStates,
+					FailedRoutes: state.FailedRoutes,
+					RoundTrip:    state.RoundTrip,
+				}
+				if state.RoundTrip.Improved() {
+					improved = true
+				}
+			}
+			continue
+		}
+
 		if len(availableStates) > 0 {
-			available[search] = availableStates
+			available[search] = &SearchState{
+				Flights:      availableStates,
+				FailedRoutes: state.FailedRoutes,
+				Calendar:     state.Calendar,
+			}
 			if statesImproved {
 				improved = true
 			}
@@ -78,11 +188,11 @@ func (f FlightSearchStates) OnlyAvailable() (available FlightSearchStates, impro
 	return
 }
 
-func (f FlightSearchStates) getFlightStates(search *FlightSearch) FlightStates {
-	states, ok := f[search]
+func (f FlightSearchStates) getSearchState(search *FlightSearch) *SearchState {
+	state, ok := f[search]
 	if !ok {
-		states = make(FlightStates)
-		f[search] = states
+		state = &SearchState{Flights: make(FlightStates)}
+		f[search] = state
 	}
-	return states
+	return state
 }
@@ -1,44 +1,150 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/model"
 )
 
 // Container to keep and update flight state.
 type SearchStateUpdater struct {
-	states   FlightSearchStates
-	searches []*FlightSearch
-	fetcher  *FlightFetcher
-	notifier SearchUpdateNotifier
+	states      FlightSearchStates
+	searches    []*FlightSearch
+	occurrences map[occurrence]*FlightSearch // weekly searches expanded so far
+	fetcher     *FlightFetcher
+	notifier    SearchUpdateNotifier
+}
+
+// A weekly search's occurrence starting on day.
+type occurrence struct {
+	search *FlightSearch
+	day    time.Time
 }
 
 func NewSearchStateUpdater(searches []*FlightSearch, fetcher *FlightFetcher, notifier SearchUpdateNotifier) *SearchStateUpdater {
 	s := &SearchStateUpdater{
-		states:   NewFlightSearchStates(),
-		searches: searches,
-		fetcher:  fetcher,
-		notifier: notifier,
+		states:      NewFlightSearchStates(),
+		searches:    searches,
+		occurrences: make(map[occurrence]*FlightSearch),
+		fetcher:     fetcher,
+		notifier:    notifier,
 	}
 
 	return s
 }
 
 // Fetch latest flight info, update state, and send updates to the notifier.
-func (c *SearchStateUpdater) Update() error {
-	today := date(time.Now())
-	for _, search := range c.searches {
-		if !today.After(date(search.MinDepartureTime)) {
-			flights, err := c.fetcher.Fetch(search)
+// Stops early with an error if ctx is done.
+// Searches with failed routes are still updated and notified, then the failures
+// are returned together as a *client.PartialError.
+func (c *SearchStateUpdater) Update(ctx context.Context) error {
+	allFailures := &client.PartialError{}
+
+	today := localDate(time.Now())
+	for _, search := range c.activeSearches(today) {
+		flights, failedRoutes, err := c.fetch(ctx, search, allFailures)
+		if err != nil {
+			return err
+		}
+		c.states.Update(search, flights, failedRoutes)
+
+		if search.Return != nil {
+			returnFlights, returnFailedRoutes, err := c.fetch(ctx, search.Return, allFailures)
 			if err != nil {
 				return err
 			}
-			c.states.Update(search, flights)
+			c.states.UpdateRoundTrip(search, returnFlights, returnFailedRoutes)
 		}
 	}
 
-	if err := c.notifier.Notify(c.states); err != nil {
+	if err := c.notifier.Notify(ctx, c.states); err != nil {
+		return err
+	}
+	if err := c.notifySchemaDrift(ctx); err != nil {
 		return err
 	}
 
+	if len(allFailures.Failures) > 0 {
+		return allFailures
+	}
 	return nil
 }
+
+// Searches with days left to search on or after today. Weekly searches are expanded into
+// their upcoming occurrences, each kept across updates so its state carries over.
+// Occurrences and searches whose days have all passed are retired, along with their state.
+func (c *SearchStateUpdater) activeSearches(today time.Time) []*FlightSearch {
+	for key, search := range c.occurrences {
+		if today.After(search.lastDepartureDay()) {
+			delete(c.occurrences, key)
+			delete(c.states, search)
+		}
+	}
+
+	active := make([]*FlightSearch, 0, len(c.searches))
+	for _, search := range c.searches {
+		if !search.Recurring() {
+			if today.After(search.lastDepartureDay()) {
+				delete(c.states, search)
+			} else {
+				active = append(active, search)
+			}
+			continue
+		}
+
+		for _, day := range search.occurrenceDays(today) {
+			key := occurrence{search, day}
+			if _, ok := c.occurrences[key]; !ok {
+				c.occurrences[key] = search.occurrenceOn(day)
+			}
+			active = append(active, c.occurrences[key])
+		}
+	}
+	return active
+}
+
+// Report any drift in the shape of responses, if both the provider and the notifier support it.
+func (c *SearchStateUpdater) notifySchemaDrift(ctx context.Context) error {
+	source, ok := c.fetcher.provider.(SchemaDriftSource)
+	if !ok {
+		return nil
+	}
+	notifier, ok := c.notifier.(SchemaDriftNotifier)
+	if !ok {
+		return nil
+	}
+	if drift := source.TakeSchemaDrift(); drift != nil {
+		return notifier.NotifySchemaDrift(ctx, drift)
+	}
+	return nil
+}
+
+// Fetch a search's flights, adding any route failures to allFailures.
+// Only returns an error if the search failed entirely.
+func (c *SearchStateUpdater) fetch(ctx context.Context, search *FlightSearch, allFailures *client.PartialError) ([]*model.Flight, []Route, error) {
+	flights, err := c.fetcher.Fetch(ctx, search)
+	var partialErr *client.PartialError
+	if errors.As(err, &partialErr) {
+		allFailures.Failures = append(allFailures.Failures, partialErr.Failures...)
+		return flights, partialErrorRoutes(partialErr), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return flights, nil, nil
+}
+
+func partialErrorRoutes(partialErr *client.PartialError) []Route {
+	routes := make([]Route, len(partialErr.Failures))
+	for i, failure := range partialErr.Failures {
+		routes[i] = Route{
+			OriginAirport:      strings.ToUpper(failure.Origin),
+			DestinationAirport: strings.ToUpper(failure.Destination),
+			DepartureDate:      failure.DepartureDate.Format(dateLayout),
+		}
+	}
+	return routes
+}
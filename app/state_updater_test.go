@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/client/fake_api"
+)
+
+// Notifier keeping the flight updates of the latest cycle, by flight number.
+type recordingNotifier struct {
+	updates map[string]FlightStateChange
+	html    string
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, searchStates FlightSearchStates) error {
+	r.updates = make(map[string]FlightStateChange)
+	for _, state := range searchStates {
+		for _, flightState := range state.Flights {
+			r.updates[flightState.Flight.Segments[0].FlightNumber] = flightState.Update
+		}
+	}
+
+	html, err := BodyToHTML(NewBody(searchStates))
+	r.html = html
+	return err
+}
+
+func newFakeClient(t *testing.T, server *fake_api.Server, retry client.RetryPolicy) *client.Client {
+	c, err := client.NewClientWithOptions(client.Options{
+		BaseUrl:           server.URL,
+		RequestsPerSecond: -1,
+		Retry:             retry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func nonstop(day time.Time, origin, flightNumber string, hour int) []fake_api.FixtureSegment {
+	departure := day.Add(time.Duration(hour) * time.Hour)
+	return []fake_api.FixtureSegment{{
+		Origin:       origin,
+		Destination:  "BUR",
+		Departure:    departure,
+		Arrival:      departure.Add(90 * time.Minute),
+		FlightNumber: flightNumber,
+	}}
+}
+
+func searchesOn(t *testing.T, day time.Time, extra string) []*FlightSearch {
+	searches, err := FlightSearchesFromJson([]byte(`[{
+		"origin_airports": ["SFO", "OAK"],
+		"destination_airports": ["BUR"],
+		"min_departure_time": "` + day.Format(dateLayout) + `T06:00:00Z",
+		"max_arrival_time": "` + day.Format(dateLayout) + `T23:00:00Z"` + extra + `
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return searches
+}
+
+func TestSearchStateUpdaterUpdate(t *testing.T) {
+	server := fake_api.NewServer()
+	defer server.Close()
+
+	day := date(time.Now()).AddDate(0, 0, 30)
+	sfo := fake_api.Route{Origin: "SFO", Destination: "BUR", DepartureDate: day.Format(dateLayout)}
+	oak := fake_api.Route{Origin: "OAK", Destination: "BUR", DepartureDate: day.Format(dateLayout)}
+	fare := fake_api.FixtureFare{FareType: "WGA", Cents: 9900, SeatsAvailable: 3}
+
+	sfoFlights := fake_api.NewFixture().
+		Flight(nonstop(day, "SFO", "100", 8), fare).
+		Flight(nonstop(day, "SFO", "200", 12), fare).
+		Flight(nonstop(day, "SFO", "300", 16), fare)
+	changedSfoFlights := sfoFlights.SetFare(0, 0, 5900).SoldOut(1, 0).RemoveFlight(2)
+	oakFlights := fake_api.NewFixture().Flight(nonstop(day, "OAK", "400", 9), fare)
+
+	server.Script(sfo, fake_api.Respond(sfoFlights.Response()), fake_api.Respond(changedSfoFlights.Response()))
+	server.Script(oak,
+		fake_api.Respond(oakFlights.Response()),
+		fake_api.Respond(oakFlights.Response()),
+		fake_api.Respond(fake_api.NewFixture().Response()),
+		fake_api.Fail(http.StatusInternalServerError))
+
+	notifier := &recordingNotifier{}
+	fetcher := NewFlightFetcher(newFakeClient(t, server, client.RetryPolicy{MaxAttempts: 1}))
+	updater := NewSearchStateUpdater(searchesOn(t, day, ""), fetcher, notifier)
+
+	for i, cycle := range []struct {
+		updates       map[string]FlightStateChange
+		routeFailures int
+	}{
+		{updates: map[string]FlightStateChange{"100": Added, "200": Added, "300": Added, "400": Added}},
+		// Price drop, sold out and removed flight
+		{updates: map[string]FlightStateChange{"100": FareDecrease, "200": FareIncrease, "300": Removed, "400": Unchanged}},
+		{updates: map[string]FlightStateChange{"100": Unchanged, "200": Unchanged, "400": Removed}},
+		// A failed route keeps its removed flight removed
+		{updates: map[string]FlightStateChange{"100": Unchanged, "200": Unchanged, "400": Removed}, routeFailures: 1},
+	} {
+		err := updater.Update(context.Background())
+		var partialErr *client.PartialError
+		if cycle.routeFailures == 0 && err != nil {
+			t.Fatalf("cycle %d: unexpected error: %v", i+1, err)
+		} else if cycle.routeFailures > 0 && (!errors.As(err, &partialErr) || len(partialErr.Failures) != cycle.routeFailures) {
+			t.Fatalf("cycle %d: expected %d route failures, got %v", i+1, cycle.routeFailures, err)
+		}
+
+		if len(notifier.updates) != len(cycle.updates) {
+			t.Errorf("cycle %d: expected updates %v, got %v", i+1, cycle.updates, notifier.updates)
+		}
+		for flightNumber, update := range cycle.updates {
+			if got, ok := notifier.updates[flightNumber]; !ok || got != update {
+				t.Errorf("cycle %d: flight %s: expected update %v, got %v", i+1, flightNumber, update, got)
+			}
+		}
+		if notifier.html == "" {
+			t.Errorf("cycle %d: empty email body", i+1)
+		}
+	}
+}
+
+func TestSearchStateUpdaterExpiredSearch(t *testing.T) {
+	day := date(time.Now()).AddDate(0, 0, 30)
+	searches := searchesOn(t, day, "")
+	updater := NewSearchStateUpdater(searches, nil, &recordingNotifier{})
+	updater.states.Update(searches[0], nil, nil)
+
+	if active := updater.activeSearches(day); len(active) != 1 {
+		t.Fatalf("expected the search to be active on its day, got %d searches", len(active))
+	}
+	if active := updater.activeSearches(day.AddDate(0, 0, 1)); len(active) != 0 {
+		t.Fatalf("expected no active searches the day after, got %d", len(active))
+	}
+	if _, ok := updater.states[searches[0]]; ok {
+		t.Error("expected the expired search's state to be removed")
+	}
+}
@@ -8,24 +8,34 @@ import (
 var (
 	funcMap = template.FuncMap{
 		"tripRowStyle": func(trip *Trip) string {
-			switch trip.Update {
-			case FareIncrease:
-				return "background-color: #F79F79"
-			case FareDecrease:
-				return "background-color: #E3F09B"
-			case Added:
-				return "background-color: #87B6A7"
-			case Removed:
-				return "background-color: #F79F79"
-			default:
-				return ""
-			}
+			return updateStyle(trip.Update)
+		},
+		"roundTripRowStyle": func(roundTrip *RoundTripRow) string {
+			return updateStyle(roundTrip.Update)
+		},
+		"calendarCellStyle": func(cell *CalendarCell) string {
+			return updateStyle(cell.Update)
 		},
 	}
 
 	htmlTemplate = template.Must(template.New("HtmlBody").Funcs(funcMap).Parse(htmlTemplateDef))
 )
 
+func updateStyle(update FlightStateChange) string {
+	switch update {
+	case FareIncrease:
+		return "background-color: #F79F79"
+	case FareDecrease:
+		return "background-color: #E3F09B"
+	case Added:
+		return "background-color: #87B6A7"
+	case Removed:
+		return "background-color: #F79F79"
+	default:
+		return ""
+	}
+}
+
 func BodyToHTML(body *Body) (string, error) {
 	var buf bytes.Buffer
 	if err := htmlTemplate.Execute(&buf, body); err != nil {
@@ -40,14 +50,60 @@ var htmlTemplateDef = `
   <body style="font-family: Arial, Helvetica, sans-serif">
     {{range .SearchGroups}}
       <div>
-        <h3 style="margin-bottom: 3px;">{{.Date}}</h3>
+        <h3 style="margin-bottom: 3px;">{{.Date}}{{if .ReturnDate}} &ndash; {{.ReturnDate}}{{end}}{{if .LastDate}} &ndash; {{.LastDate}}{{end}}</h3>
+        <div><i>{{.Window}}{{if .ReturnWindow}}; return: {{.ReturnWindow}}{{end}}</i></div>
         {{if .MaxFare}}<i>Max {{.MaxFare}}</i>{{end}}
+        {{if .MaxTripFare}}<i>Max trip {{.MaxTripFare}}</i>{{end}}
+        {{if .Criteria}}<i>Limits: {{.Criteria}}</i>{{end}}
         {{if .Note}}<i>({{.Note}})</i>{{end}}
+        {{if .PromoCode}}<i>Promo code {{.PromoCode}}{{if .PromoCodeRejected}} <span style="color: #B33">rejected by Southwest, showing regular fares</span>{{end}}</i>{{end}}
+        {{if .FailedRoutes}}<div style="color: #B33"><i>Incomplete results, unable to search {{.FailedRoutes}}</i></div>{{end}}
+        {{if .Calendar}}
+        <table style="border-collapse: collapse; margin: 5px 0px">
+          <tr>
+            {{range .Calendar}}<th style="text-align: left; padding: 0px 15px 0px 0px">{{.Date}}</th>{{end}}
+          </tr>
+          <tr>
+            {{range .Calendar}}<td style="padding: 0px 15px 0px 0px; {{. | calendarCellStyle}}">{{if .Cost}}{{.Cost}}{{else}}&ndash;{{end}}</td>{{end}}
+          </tr>
+        </table>
+        {{end}}
+        {{if .RoundTrips}}
+        <table style="border-collapse: collapse">
+          <thead>
+            <th colspan="5" style="text-align: left; padding: 0px 15px 0px 0px">Outbound</th>
+            <th colspan="5" style="text-align: left; padding: 0px 15px 0px 0px">Return</th>
+            <th style="text-align: left; padding: 0px 15px 0px 0px">Stay</th>
+            <th style="text-align: left; padding: 0px 15px 0px 0px">Price</th>
+          </thead>
+          <tbody>
+            {{range .RoundTrips}}
+              <tr style="{{. | roundTripRowStyle}}">
+                <td style="padding: 0px 15px 0px 0px">{{.Outbound.OriginAirport}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Outbound.DepartureTime}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Outbound.DestinationAirport}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Outbound.ArrivalTime}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Outbound.FlightNumbers}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Return.OriginAirport}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Return.DepartureTime}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Return.DestinationAirport}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Return.ArrivalTime}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Return.FlightNumbers}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Stay}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Cost}}{{if .PerPersonCost}} <small>({{.PerPersonCost}} each)</small>{{end}}</td>
+              </tr>
+            {{end}}
+          </tbody>
+        </table>
+        {{else}}
         <table style="border-collapse: collapse">
           <thead>
             <th colspan="2" style="text-align: left; padding: 0px 15px 0px 0px">From</th>
             <th colspan="2" style="text-align: left; padding: 0px 15px 0px 0px">To</th>
+            <th style="text-align: left; padding: 0px 15px 0px 0px">Duration</th>
+            <th style="text-align: left; padding: 0px 15px 0px 0px">Flight</th>
             <th style="text-align: left; padding: 0px 15px 0px 0px">Stops</th>
+            <th style="text-align: left; padding: 0px 15px 0px 0px">Fare</th>
             <th style="text-align: left; padding: 0px 15px 0px 0px">Price</th>
           </thead>
           <tbody>
@@ -57,11 +113,16 @@ var htmlTemplateDef = `
                 <td style="padding: 0px 15px 0px 0px">{{.DepartureTime}}</td>
                 <td style="padding: 0px 15px 0px 0px">{{.DestinationAirport}}</td>
                 <td style="padding: 0px 15px 0px 0px">{{.ArrivalTime}}</td>
-                <td style="padding: 0px 15px 0px 0px">{{.Stops}}</td>
-                <td style="padding: 0px 15px 0px 0px">{{.Cost}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Duration}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.FlightNumbers}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Stops}}{{if .Layovers}} <small>({{.Layovers}})</small>{{end}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.FareClass}}</td>
+                <td style="padding: 0px 15px 0px 0px">{{.Cost}}{{if .PerPersonCost}} <small>({{.PerPersonCost}} each)</small>{{end}}{{if .PromoSavings}} <small>(promo saves {{.PromoSavings}} each)</small>{{end}}</td>
               </tr>
             {{end}}
           </tbody>
+        </table>
+        {{end}}
       </div>
     {{end}}
   </body>
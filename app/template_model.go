@@ -13,10 +13,25 @@ type Body struct {
 }
 
 type SearchGroup struct {
-	Date    string
-	MaxFare *string
-	Note    *string
-	Trips   []*Trip
+	Date              string
+	Window            string // departure and arrival times
+	MaxFare           *string
+	Criteria          *string // stop, travel time and connection limits
+	Note              *string
+	FailedRoutes      *string
+	PromoCode         *string
+	PromoCodeRejected bool
+	Trips             []*Trip
+
+	// Only set for round trip searches, which show RoundTrips instead of Trips
+	ReturnDate   *string
+	ReturnWindow *string
+	MaxTripFare  *string
+	RoundTrips   []*RoundTripRow
+
+	// Only set for flexible date searches
+	LastDate *string
+	Calendar []*CalendarCell
 }
 
 type Trip struct {
@@ -24,44 +39,84 @@ type Trip struct {
 	DestinationAirport string
 	DepartureTime      string
 	ArrivalTime        string
+	Duration           string // elapsed travel time
+	FlightNumbers      string // e.g. "#1234, #567", empty if unknown
 	Stops              int
-	Cost               string
+	Layovers           string  // stop airports and layover durations, empty for nonstop flights
+	FareClass          string  // of the cheapest available fare
+	Cost               string  // total for the party
+	PerPersonCost      *string // only set for parties of more than one
+	PromoSavings       *string // per person, only set if the promo code is cheaper
 	Update             FlightStateChange
 }
 
+// Cheapest per person fare on one day of a flexible date search.
+type CalendarCell struct {
+	Date   string
+	Cost   *string // nil if no fares are available
+	Update FlightStateChange
+}
+
+type RoundTripRow struct {
+	Outbound      *Trip
+	Return        *Trip
+	Stay          string
+	Cost          string  // total for the party
+	PerPersonCost *string // only set for parties of more than one
+	Update        FlightStateChange
+}
+
 func NewBody(searches FlightSearchStates) *Body {
 	searchGroups := make([]*SearchGroup, 0, len(searches))
 
 	for _, search := range sortedFlightSearches(searches) {
-		updates := searches[search]
-		trips := make([]*Trip, 0, len(updates))
-
-		for _, update := range sortedFlightStates(updates) {
-			var cost string
-			if fare := update.Flight.CheapestAvailableFare(); fare != nil {
-				fareCents := update.Flight.CheapestAvailableFare().Cents
-				cost = fmt.Sprintf("$ %d.%02d", fareCents/100, fareCents%100)
-			}
+		state := searches[search]
+		trips := make([]*Trip, 0, len(state.Flights))
 
-			trips = append(trips, &Trip{
-				OriginAirport:      update.Flight.OriginAirport,
-				DestinationAirport: update.Flight.DestinationAirport,
-				DepartureTime:      update.Flight.DepartureLocalTime.Format("3:04 PM"),
-				ArrivalTime:        update.Flight.ArrivalLocalTime.Format("3:04 PM"),
-				Stops:              len(update.Flight.Stops),
-				Cost:               cost,
-				Update:             update.Update,
-			})
+		promoCodeRejected := false
+		for _, update := range sortedFlightStates(state.Flights) {
+			if update.Flight.PromoCodeRejected {
+				promoCodeRejected = true
+			}
+			trips = append(trips, newTrip(update.Flight, update.Update, search.Flexible()))
 		}
 
 		searchGroup := &SearchGroup{
-			Date:  search.MinDepartureTime.Format("Mon Jan 2 2006"),
-			Note:  search.Note,
-			Trips: trips,
+			Date:              search.MinDepartureTime.Format("Mon Jan 2 2006"),
+			Window:            windowString(search),
+			Note:              search.Note,
+			PromoCode:         search.PromoCode,
+			PromoCodeRejected: promoCodeRejected,
+			Trips:             trips,
+		}
+
+		if criteria := criteriaString(search); criteria != "" {
+			searchGroup.Criteria = &criteria
+		}
+
+		if state.Incomplete() {
+			failedRoutes := routesString(state.AllFailedRoutes())
+			searchGroup.FailedRoutes = &failedRoutes
+		}
+
+		if state.RoundTrip != nil {
+			addRoundTrips(searchGroup, search, state.RoundTrip)
 		}
 
+		if search.Flexible() {
+			addCalendar(searchGroup, search, state.Calendar)
+		}
+
+		var maxFare string
 		if search.MaxFareCents != nil {
-			maxFare := fmt.Sprintf("$ %d.%02d", *search.MaxFareCents/100, *search.MaxFareCents%100)
+			maxFare = centsString(*search.MaxFareCents)
+		} else if search.MaxFarePoints != nil {
+			maxFare = pointsString(*search.MaxFarePoints)
+		}
+		if maxFare != "" {
+			if partySize := search.FareQuery().PartySize(); partySize > 1 {
+				maxFare = fmt.Sprintf("%s per person, %d passengers", maxFare, partySize)
+			}
 			searchGroup.MaxFare = &maxFare
 		}
 
@@ -70,6 +125,103 @@ func NewBody(searches FlightSearchStates) *Body {
 	return &Body{SearchGroups: searchGroups}
 }
 
+// Trip times include the day of the week when showDay is set, for searches spanning several days.
+func newTrip(flight *model.Flight, update FlightStateChange, showDay bool) *Trip {
+	timeLayout := "3:04 PM"
+	if showDay {
+		timeLayout = "Mon Jan 2 3:04 PM"
+	}
+
+	var cost, fareClass string
+	var perPersonCost *string
+	if fare := flight.CheapestAvailableFare(); fare != nil {
+		fareClass = fare.Class.String()
+		if fare.Refundable {
+			fareClass += ", refundable"
+		}
+		cost = priceString(flight.CheapestAvailablePartyAmount(), fare.Currency)
+		if flight.PartySize > 1 {
+			fareCost := priceString(fare.Amount(), fare.Currency)
+			perPersonCost = &fareCost
+		}
+	}
+
+	var promoSavings *string
+	if savings := flight.PromoCodeSavings(); savings > 0 {
+		savingsStr := priceString(savings, flight.CheapestAvailableFare().Currency)
+		promoSavings = &savingsStr
+	}
+
+	return &Trip{
+		OriginAirport:      flight.OriginAirport,
+		DestinationAirport: flight.DestinationAirport,
+		DepartureTime:      flight.DepartureLocalTime.Format(timeLayout),
+		ArrivalTime:        flight.ArrivalLocalTime.Format(timeLayout),
+		Duration:           durationString(flight.Duration()),
+		FlightNumbers:      flightNumbersString(flight),
+		Stops:              len(flight.Stops),
+		Layovers:           layoversString(flight),
+		FareClass:          fareClass,
+		Cost:               cost,
+		PerPersonCost:      perPersonCost,
+		PromoSavings:       promoSavings,
+		Update:             update,
+	}
+}
+
+// Show the cheapest round trips. Only the cheapest is highlighted with the change in trip price.
+func addRoundTrips(searchGroup *SearchGroup, search *FlightSearch, state *RoundTripState) {
+	returnDate := search.Return.MinDepartureTime.Format("Mon Jan 2 2006")
+	searchGroup.ReturnDate = &returnDate
+	returnWindow := windowString(search.Return)
+	searchGroup.ReturnWindow = &returnWindow
+
+	if search.MaxTripFareCents != nil {
+		maxTripFare := centsString(*search.MaxTripFareCents)
+		searchGroup.MaxTripFare = &maxTripFare
+	} else if search.MaxTripFarePoints != nil {
+		maxTripFare := pointsString(*search.MaxTripFarePoints)
+		searchGroup.MaxTripFare = &maxTripFare
+	}
+
+	searchGroup.RoundTrips = make([]*RoundTripRow, len(state.Cheapest))
+	for i, roundTrip := range state.Cheapest {
+		row := &RoundTripRow{
+			Outbound: newTrip(roundTrip.Outbound, Unchanged, false),
+			Return:   newTrip(roundTrip.Return, Unchanged, false),
+			Stay:     durationString(roundTrip.Stay()),
+			Cost:     priceString(roundTrip.PartyAmount(), roundTrip.Currency()),
+		}
+		if roundTrip.Outbound.PartySize > 1 {
+			perPersonCost := priceString(roundTrip.Amount(), roundTrip.Currency())
+			row.PerPersonCost = &perPersonCost
+		}
+		if i == 0 {
+			row.Update = state.Update
+		}
+		searchGroup.RoundTrips[i] = row
+	}
+}
+
+// Show the date range and the cheapest fare of each day, highlighted with its change.
+func addCalendar(searchGroup *SearchGroup, search *FlightSearch, calendar []*CalendarDay) {
+	lastDate := search.lastDepartureDay().Format("Mon Jan 2 2006")
+	searchGroup.LastDate = &lastDate
+
+	searchGroup.Calendar = make([]*CalendarCell, len(calendar))
+	for i, day := range calendar {
+		cell := &CalendarCell{
+			Date:   day.Date.Format("Mon Jan 2"),
+			Update: day.Update,
+		}
+		if day.Cheapest != nil {
+			cost := priceString(day.Cheapest.Amount(), day.Cheapest.Currency)
+			cell.Cost = &cost
+		}
+		searchGroup.Calendar[i] = cell
+	}
+}
+
 // Helper to sort FlightSearch by MinDepartureTime
 type FlightSearchByMinDepartureTime []*FlightSearch
 
@@ -1,10 +1,145 @@
 package app
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
 )
 
 // Truncate a UTC time to the its date only
 func date(t time.Time) time.Time {
 	return t.Truncate(24 * time.Hour)
 }
+
+// Local calendar date of t as UTC midnight, comparable with dates of config times
+func localDate(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Comma separated routes, e.g. "SFO-BUR on 2016-01-31, OAK-BUR on 2016-01-31"
+func routesString(routes []Route) string {
+	strs := make([]string, len(routes))
+	for i, route := range routes {
+		strs[i] = route.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+// Dollar string of cents, e.g. "$ 72.00"
+func centsString(cents uint32) string {
+	return fmt.Sprintf("$ %d.%02d", cents/100, cents%100)
+}
+
+// Rapid Rewards points string, e.g. "12,345 pts"
+func pointsString(points uint32) string {
+	digits := fmt.Sprintf("%d", points)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String() + " pts"
+}
+
+// Short duration string in days, hours and minutes, e.g. "2d 3h", "1h 15m"
+func durationString(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	parts := make([]string, 0, 3)
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Flight numbers of a flight's segments, e.g. "#1234, #567", or "" if unknown
+func flightNumbersString(flight *model.Flight) string {
+	numbers := make([]string, 0, len(flight.Segments))
+	for _, number := range flight.FlightNumbers() {
+		if number != "" {
+			numbers = append(numbers, "#"+number)
+		}
+	}
+	return strings.Join(numbers, ", ")
+}
+
+// Stop airports with their layovers, e.g. "DEN 1h 5m, PHX 45m", or "" for nonstop flights
+func layoversString(flight *model.Flight) string {
+	layovers := flight.Layovers()
+	if len(layovers) != len(flight.Stops) {
+		return strings.Join(flight.Stops, ", ")
+	}
+	strs := make([]string, len(layovers))
+	for i, layover := range layovers {
+		strs[i] = flight.Stops[i] + " " + durationString(layover)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// Departure and arrival times of a search, local to each airport,
+// e.g. "Depart 6:00 AM – 9:00 AM, arrive by 1:00 PM"
+func windowString(search *FlightSearch) string {
+	const layout = "3:04 PM"
+
+	depart := "Depart after " + search.MinDepartureTime.Format(layout)
+	if search.MaxDepartureTime != nil {
+		depart = fmt.Sprintf("Depart %s – %s", search.MinDepartureTime.Format(layout), search.MaxDepartureTime.Format(layout))
+	}
+	arrive := "arrive by " + search.MaxArrivalTime.Format(layout)
+	if search.MinArrivalTime != nil {
+		arrive = fmt.Sprintf("arrive %s – %s", search.MinArrivalTime.Format(layout), search.MaxArrivalTime.Format(layout))
+	}
+	return depart + ", " + arrive
+}
+
+// Stop, travel time and connection limits of a search, e.g. "max 1 stop, no connections through DEN"
+func criteriaString(search *FlightSearch) string {
+	criteria := make([]string, 0)
+	if search.MaxNumberStops != nil {
+		if *search.MaxNumberStops == 0 {
+			criteria = append(criteria, "nonstop only")
+		} else if *search.MaxNumberStops == 1 {
+			criteria = append(criteria, "max 1 stop")
+		} else {
+			criteria = append(criteria, fmt.Sprintf("max %d stops", *search.MaxNumberStops))
+		}
+	}
+	if search.MaxDuration != nil {
+		criteria = append(criteria, "max travel time "+durationString(parseDuration(search.MaxDuration)))
+	}
+	if search.MaxLayover != nil {
+		criteria = append(criteria, "layovers up to "+durationString(parseDuration(search.MaxLayover)))
+	}
+	if search.MinConnection != nil {
+		criteria = append(criteria, "connections of at least "+durationString(parseDuration(search.MinConnection)))
+	}
+	if len(search.ExcludedConnectionAirports) > 0 {
+		criteria = append(criteria, "no connections through "+strings.Join(search.ExcludedConnectionAirports, ", "))
+	}
+	if search.Filters != nil {
+		criteria = append(criteria, "filters "+search.Filters.String())
+	}
+	return strings.Join(criteria, ", ")
+}
+
+// Price string of an amount in a currency
+func priceString(amount uint32, currency model.Currency) string {
+	if currency == model.Points {
+		return pointsString(amount)
+	}
+	return centsString(amount)
+}
@@ -2,6 +2,7 @@ package api_model
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -9,7 +10,14 @@ type LocalDateTime struct {
 	time.Time
 }
 
+const localDateTimeLayout = "2006-01-02T15:04"
+
 var _ json.Marshaler = (*LocalDateTime)(nil)
+var _ json.Unmarshaler = (*LocalDateTime)(nil)
+
+func (l LocalDateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Time.Format(localDateTimeLayout))
+}
 
 func (l *LocalDateTime) UnmarshalJSON(value []byte) error {
 	// Remove quotes
@@ -17,25 +25,32 @@ func (l *LocalDateTime) UnmarshalJSON(value []byte) error {
 		value = value[1 : len(value)-1]
 	}
 
-	parsed, err := time.Parse("2006-01-02T15:04", string(value))
+	parsed, err := time.Parse(localDateTimeLayout, string(value))
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid local date time %s, expected layout %s", value, localDateTimeLayout)
 	}
 	l.Time = parsed
 	return nil
 }
 
+// Fields tagged omitempty are optional; the client warns when any other field is missing.
+
 type CurrencyPrice struct {
-	TotalFareCents uint32 `json:"totalFareCents"`
+	TotalFareCents  uint32 `json:"totalFareCents"`
+	TotalFarePoints uint32 `json:"totalFarePoints,omitempty"` // only set for currency-type=Points
+	BaseFareCents   uint32 `json:"baseFareCents,omitempty"`
+	TotalTaxesCents uint32 `json:"totalTaxesCents,omitempty"`
 }
 
 type FareProduct struct {
-	FareType       string         `json:"fareType"`
+	FareType       string         `json:"fareType"` // e.g. "WGA", "ANY", "BUS"
 	CurrencyPrice  *CurrencyPrice `json:"currencyPrice"`
 	SeatsAvailable string         `json:"seatsAvailable"`
+	Refundable     *bool          `json:"refundable,omitempty"`
 }
 
 type Segment struct {
+	FlightNumber           string        `json:"flightNumber,omitempty"`
 	OriginationAirportCode string        `json:"originationAirportCode"`
 	DestinationAirportCode string        `json:"destinationAirportCode"`
 	DepartureDateTime      LocalDateTime `json:"departureDateTime"`
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store of raw list flights responses. Keys identify a request, including its
+// route, date and other query parameters.
+// Implementations expire entries after their TTL and must be safe for concurrent use.
+type ResponseCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, body []byte)
+}
+
+type memoryCacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// In memory ResponseCache.
+type MemoryCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+var _ ResponseCache = &MemoryCache{}
+
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		ttl:     ttl,
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+func (m *MemoryCache) Set(key string, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop expired entries so the cache doesn't grow across cycles
+	now := time.Now()
+	for k, entry := range m.entries {
+		if now.After(entry.expires) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = memoryCacheEntry{body, now.Add(m.ttl)}
+}
+
+type diskCacheEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Body    []byte    `json:"body"`
+}
+
+// ResponseCache storing one file per request in a directory, so entries survive restarts.
+// Expired files are deleted, so the directory doesn't grow across cycles.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ ResponseCache = &DiskCache{}
+
+// NewDiskCache returns a cache in dir, creating it if needed.
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, ttl: ttl}, nil
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if time.Now().After(entry.Expires) {
+		os.Remove(d.path(key))
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+// Set writes the entry. Failures are ignored, as they only cost a cache miss later.
+func (d *DiskCache) Set(key string, body []byte) {
+	d.sweep()
+
+	data, err := json.Marshal(&diskCacheEntry{key, time.Now().Add(d.ttl), body})
+	if err != nil {
+		return
+	}
+
+	// Write then rename, so readers never see a partial file
+	tmp, err := ioutil.TempFile(d.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete expired entries, and temp files left by interrupted writes, at most once per TTL.
+// Files are judged by modification time, which is when they were written.
+func (d *DiskCache) sweep() {
+	d.mu.Lock()
+	now := time.Now()
+	if now.Sub(d.lastSweep) < d.ttl {
+		d.mu.Unlock()
+		return
+	}
+	d.lastSweep = now
+	d.mu.Unlock()
+
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !isDiskCacheFile(name) {
+			continue
+		}
+		if now.Sub(file.ModTime()) > d.ttl {
+			os.Remove(filepath.Join(d.dir, name))
+		}
+	}
+}
+
+// Whether a file name is one the cache writes: an entry named by its key's hash, or a temp file.
+// Other files sharing the directory are left alone.
+func isDiskCacheFile(name string) bool {
+	if strings.HasPrefix(name, "tmp-") {
+		return true
+	}
+	hash := strings.TrimSuffix(name, ".json")
+	if len(hash) != 2*sha256.Size || hash+".json" != name || strings.ToLower(hash) != hash {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Counts of cache lookups since the client was created.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+func (c *Client) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&c.cacheHits),
+		Misses: atomic.LoadUint64(&c.cacheMisses),
+	}
+}
+
+// GET a URL through the cache, if the client has one.
+func (c *Client) cachedGet(ctx context.Context, key string, urlStr string) ([]byte, error) {
+	if c.cache == nil {
+		return c.getWithRetry(ctx, urlStr)
+	}
+
+	if body, ok := c.cache.Get(key); ok {
+		atomic.AddUint64(&c.cacheHits, 1)
+		return body, nil
+	}
+	atomic.AddUint64(&c.cacheMisses, 1)
+
+	body, err := c.getWithRetry(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Set(key, body)
+	return body, nil
+}
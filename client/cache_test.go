@@ -0,0 +1,48 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheSweepKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Set("expired", []byte("{}"))
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{filepath.Base(cache.path("expired")), "tmp-123", "fixture.json", "schema_baseline.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := ioutil.WriteFile(path, []byte("{}"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Sweeps run at most once per TTL, and setting the expired entry already swept
+	cache.lastSweep = time.Time{}
+	cache.Set("fresh", []byte("{}"))
+
+	for name, kept := range map[string]bool{
+		filepath.Base(cache.path("expired")): false,
+		"tmp-123":                            false,
+		"fixture.json":                       true,
+		"schema_baseline.json":               true,
+		filepath.Base(cache.path("fresh")):   true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s: expected kept %v, got %v", name, kept, exists)
+		}
+	}
+}
@@ -1,35 +1,45 @@
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/alecholmes/southwest_flight_watcher/airports"
 	"github.com/alecholmes/southwest_flight_watcher/client/api_model"
 	"github.com/alecholmes/southwest_flight_watcher/model"
 )
 
 const (
-	apiKeyHeader = "X-API-Key"
-	apiKeyValue  = "l7xx8d8bfce4ee874269bedc02832674129b"
-
+	apiKeyHeader    = "X-API-Key"
 	userAgentHeader = "User-Agent"
-	userAgentValue  = "Southwest/3.0.26 (iPhone; iOS 9.1; Scale/2.00)"
-)
-
-var (
-	apiExtensionsBaseUrl = parseUrlOrPanic("https://api-extensions.southwest.com")
 )
 
 type Client struct {
-	httpClient *http.Client
+	httpClient  *http.Client
+	baseUrl     *url.URL
+	apiKey      string
+	userAgent   string
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+	logger      *log.Logger
+
+	maxConcurrency int
+
+	cache       ResponseCache
+	cacheHits   uint64
+	cacheMisses uint64
+
+	schema schemaTracker
 }
 
-// NewClient returns a new Southwest API client.
+// NewClient returns a new Southwest API client using the default options.
 // If a nil httpClient is provided, http.DefaultClient will be used.
 func NewClient(httpClient *http.Client) *Client {
 	if httpClient == nil {
@@ -37,11 +47,29 @@ func NewClient(httpClient *http.Client) *Client {
 	}
 
 	return &Client{
-		httpClient: httpClient,
+		httpClient:  httpClient,
+		baseUrl:     parseUrlOrPanic(DefaultBaseUrl),
+		apiKey:      DefaultApiKey,
+		userAgent:   DefaultUserAgent,
+		retryPolicy: DefaultRetryPolicy,
+		limiter:     newRateLimiter(DefaultRequestsPerSecond, DefaultBurst),
+		logger:      log.New(ioutil.Discard, "", 0),
+
+		maxConcurrency: DefaultMaxConcurrency,
 	}
 }
 
-func (c *Client) ListFlights(departureDate time.Time, originAirport string, destinationAirport string) ([]*model.Flight, error) {
+// ListFlights lists all flights on a date between two airports, priced for the passengers in query.
+// Errors are *RequestError, carrying the route and date.
+func (c *Client) ListFlights(ctx context.Context, departureDate time.Time, originAirport string, destinationAirport string, query FareQuery) ([]*model.Flight, error) {
+	flights, err := c.listFlightsWithPromoCode(ctx, departureDate, originAirport, destinationAirport, query)
+	if err != nil {
+		return nil, withRoute(err, originAirport, destinationAirport, departureDate)
+	}
+	return flights, nil
+}
+
+func (c *Client) listFlights(ctx context.Context, departureDate time.Time, originAirport string, destinationAirport string, query FareQuery) ([]*model.Flight, error) {
 	originAirport, err := normalizeAirportCode(originAirport)
 	if err != nil {
 		return nil, err
@@ -56,51 +84,34 @@ func (c *Client) ListFlights(departureDate time.Time, originAirport string, dest
 		return nil, err
 	}
 
-	url = apiExtensionsBaseUrl.ResolveReference(url)
+	url = c.baseUrl.ResolveReference(url)
 
 	queryValues := url.Query()
-	queryValues.Set("currency-type", "Dollars")
-	queryValues.Set("number-adult-passengers", "1")
-	queryValues.Set("number-senior-passengers", "0")
-	queryValues.Set("promo-code", "")
+	query.setQueryValues(queryValues)
 	queryValues.Set("origination-airport", originAirport)
 	queryValues.Set("destination-airport", destinationAirport)
 	queryValues.Set("departure-date", departureDate.Format("2006-01-02"))
 	url.RawQuery = queryValues.Encode()
 
-	httpReq, err := http.NewRequest("GET", url.String(), nil)
+	body, err := c.cachedGet(ctx, url.RawQuery, url.String())
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Add(apiKeyHeader, apiKeyValue)
-	httpReq.Header.Add(userAgentHeader, userAgentValue)
-
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode != 200 {
-
-		body, _ := ioutil.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("Unexpected response code listing flights. req=%s, statusCode=%d, body=%s", httpReq, httpResp.StatusCode, string(body))
-	} else if httpResp.Body == nil {
-		return nil, fmt.Errorf("No body listing flights")
+	swResponse, shape, warnings, err := decodeListFlightsResponse(body)
+	if shape != nil {
+		c.checkSchema(shape, warnings)
 	}
-
-	body, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
-		return nil, err
+		return nil, &RequestError{Kind: ErrUnexpectedResponseShape, Cause: err, StatusCode: 200}
 	}
 
-	swResponse := api_model.ListFlightsResponse{}
-	if err := json.Unmarshal(body, &swResponse); err != nil {
-		return nil, err
+	flights, warnings, err := swListFlightsResponseToFlights(swResponse, query)
+	c.checkSchema(nil, warnings)
+	if err != nil {
+		return nil, &RequestError{Kind: ErrUnexpectedResponseShape, Cause: err, StatusCode: 200}
 	}
-
-	return swListFlightsResponseToFlights(&swResponse)
+	return flights, nil
 }
 
 type FlightFilter interface {
@@ -115,52 +126,137 @@ func (m *MaxStopsFilter) Matches(flight *model.Flight) bool {
 	return len(flight.Stops) <= m.Stops
 }
 
+// Filter for flights taking at most Duration from departure to arrival.
+type MaxDurationFilter struct {
+	Duration time.Duration
+}
+
+func (m *MaxDurationFilter) Matches(flight *model.Flight) bool {
+	return flight.Duration() <= m.Duration
+}
+
+// Filter for flights whose every layover is at most Layover.
+type MaxLayoverFilter struct {
+	Layover time.Duration
+}
+
+func (m *MaxLayoverFilter) Matches(flight *model.Flight) bool {
+	for _, layover := range flight.Layovers() {
+		if layover > m.Layover {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter for flights whose every layover is at least Connection, enough time to make the next leg.
+type MinConnectionFilter struct {
+	Connection time.Duration
+}
+
+func (m *MinConnectionFilter) Matches(flight *model.Flight) bool {
+	for _, layover := range flight.Layovers() {
+		if layover < m.Connection {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter for flights that don't stop at any of Airports.
+type ExcludedConnectionsFilter struct {
+	Airports []string
+}
+
+func (e *ExcludedConnectionsFilter) Matches(flight *model.Flight) bool {
+	for _, stop := range flight.Stops {
+		for _, airport := range e.Airports {
+			if strings.EqualFold(stop, airport) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Filter for flights with an available fare of at most Cents per person.
+// Only matches fares priced in dollars.
 type MaxAvailableFareFilter struct {
 	Cents uint32
 }
 
 func (m *MaxAvailableFareFilter) Matches(flight *model.Flight) bool {
 	cheapestAvailableFare := flight.CheapestAvailableFare()
-	return cheapestAvailableFare != nil && flight.CheapestAvailableFare().Cents <= m.Cents
+	return cheapestAvailableFare != nil && cheapestAvailableFare.Currency == model.Dollars && cheapestAvailableFare.Cents <= m.Cents
 }
 
-// Filter for flights leaving after a time, inclusive.
+// Filter for flights with an available fare of at most Points per person.
+// Only matches fares priced in points.
+type MaxAvailableFarePointsFilter struct {
+	Points uint32
+}
+
+func (m *MaxAvailableFarePointsFilter) Matches(flight *model.Flight) bool {
+	cheapestAvailableFare := flight.CheapestAvailableFare()
+	return cheapestAvailableFare != nil && cheapestAvailableFare.Currency == model.Points && cheapestAvailableFare.Points <= m.Points
+}
+
+// Filter for flights leaving after a wall clock time at the origin airport, inclusive.
 type departAfterFilter struct {
 	time.Time
 }
 
 func (d *departAfterFilter) Matches(flight *model.Flight) bool {
-	return !flight.DepartureLocalTime.Before(d.Time)
+	return !flight.DepartureLocalTime.Before(airports.InLocalTime(d.Time, flight.OriginAirport))
 }
 
-// Filter for flights arriving before a time, inclusive.
+// Filter for flights leaving before a wall clock time at the origin airport, inclusive.
+type DepartBeforeFilter struct {
+	Time time.Time
+}
+
+func (d *DepartBeforeFilter) Matches(flight *model.Flight) bool {
+	return !flight.DepartureLocalTime.After(airports.InLocalTime(d.Time, flight.OriginAirport))
+}
+
+// Filter for flights arriving after a wall clock time at the destination airport, inclusive.
+type ArriveAfterFilter struct {
+	Time time.Time
+}
+
+func (a *ArriveAfterFilter) Matches(flight *model.Flight) bool {
+	return !flight.ArrivalLocalTime.Before(airports.InLocalTime(a.Time, flight.DestinationAirport))
+}
+
+// Filter for flights arriving before a wall clock time at the destination airport, inclusive.
 type arriveBeforeFilter struct {
 	time.Time
 }
 
 func (a *arriveBeforeFilter) Matches(flight *model.Flight) bool {
-	return !flight.ArrivalLocalTime.After(a.Time)
+	return !flight.ArrivalLocalTime.After(airports.InLocalTime(a.Time, flight.DestinationAirport))
 }
 
+// SearchFlights lists flights for every origin and destination pair, keeping those matching all filters.
+// The time zones of minLocalDepartureTime and maxLocalArrivalTime are ignored; they are wall clock
+// times at each origin and destination airport.
+// If some pairs fail, the flights of the others are returned with a *PartialError.
 func (c *Client) SearchFlights(
+	ctx context.Context,
 	originAirports []string,
 	destinationAirports []string,
 	minLocalDepartureTime time.Time,
 	maxLocalArrivalTime time.Time,
+	query FareQuery,
 	filters []FlightFilter) ([]*model.Flight, error) {
 
-	departureTime := minLocalDepartureTime.In(time.UTC)
-	arrivalTime := maxLocalArrivalTime.In(time.UTC)
+	departureTime := airports.WallClock(minLocalDepartureTime, time.UTC)
+	arrivalTime := airports.WallClock(maxLocalArrivalTime, time.UTC)
 
-	flights := make([]*model.Flight, 0)
-	for _, originAirport := range originAirports {
-		for _, destinationAirport := range destinationAirports {
-			someFlights, err := c.ListFlights(departureTime, originAirport, destinationAirport)
-			if err != nil {
-				return nil, err
-			}
-			flights = append(flights, someFlights...)
-		}
+	// A *PartialError still has flights to filter, and is returned with them
+	flights, err := c.listFlightsForPairs(ctx, departureTime, query, routePairs(originAirports, destinationAirports))
+	if _, partial := err.(*PartialError); err != nil && !partial {
+		return nil, err
 	}
 
 	filters = append(filters,
@@ -179,50 +275,125 @@ func (c *Client) SearchFlights(
 			filteredFlights = append(filteredFlights, flight)
 		}
 	}
-	return filteredFlights, nil
+	return filteredFlights, err
+}
+
+// Southwest fare type codes. Unlisted codes are model.UnknownFareClass.
+var fareClasses = map[string]model.FareClass{
+	"WGA":     model.WannaGetAway,
+	"WGAPLUS": model.WannaGetAwayPlus,
+	"ANY":     model.Anytime,
+	"BUS":     model.BusinessSelect,
 }
 
-func swListFlightsResponseToFlights(swResponse *api_model.ListFlightsResponse) ([]*model.Flight, error) {
+// Convert a response to flights. Flights and fares that can't be used are skipped with a warning.
+// Only fails if the response as a whole can't be interpreted.
+func swListFlightsResponseToFlights(swResponse *api_model.ListFlightsResponse, query FareQuery) ([]*model.Flight, []*SchemaWarning, error) {
 	if len(swResponse.Trips) > 1 {
-		return nil, fmt.Errorf("Unexpected number of trips: %d", len(swResponse.Trips))
+		return nil, nil, &SchemaWarning{Kind: InvalidValue, Path: "trips", Detail: fmt.Sprintf("expected at most 1 trip, got %d", len(swResponse.Trips))}
 	}
 
 	flights := make([]*model.Flight, 0)
+	warnings := make([]*SchemaWarning, 0)
 	for _, trip := range swResponse.Trips {
+		if trip == nil {
+			continue
+		}
+
 		// An airProduct will result in a flight with one or more fares
 		for _, airProduct := range trip.AirProducts {
-			fares := make([]*model.Fare, len(airProduct.FareProducts))
-			for i, fareProduct := range airProduct.FareProducts {
+			if airProduct == nil {
+				continue
+			}
+			if len(airProduct.Segments) == 0 {
+				warnings = append(warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[].segments", Detail: "flight has no segments"})
+				continue
+			}
+
+			fares := make([]*model.Fare, 0, len(airProduct.FareProducts))
+			for _, fareProduct := range airProduct.FareProducts {
+				if fareProduct == nil {
+					continue
+				}
+				if fareProduct.CurrencyPrice == nil {
+					warnings = append(warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[].fareProducts[].currencyPrice", Detail: "fare has no price"})
+					continue
+				}
 				seatsAvailable, err := strconv.ParseUint(fareProduct.SeatsAvailable, 10, 32)
 				if err != nil {
-					return nil, fmt.Errorf("Unexpected seats available value: %s", fareProduct.SeatsAvailable)
+					warnings = append(warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[].fareProducts[].seatsAvailable", Detail: fmt.Sprintf("not a number: %q", fareProduct.SeatsAvailable)})
+					continue
 				}
 
-				fares[i] = &model.Fare{
+				class, ok := fareClasses[fareProduct.FareType]
+				if !ok {
+					warnings = append(warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[].fareProducts[].fareType", Detail: fmt.Sprintf("unknown fare type %q", fareProduct.FareType)})
+				}
+				if !query.allowsFareClass(class) {
+					continue
+				}
+
+				fare := &model.Fare{
 					Cents:          fareProduct.CurrencyPrice.TotalFareCents,
+					Currency:       query.Currency,
 					SeatsAvailable: uint32(seatsAvailable),
+					FareType:       fareProduct.FareType,
+					Class:          class,
+					BaseFareCents:  fareProduct.CurrencyPrice.BaseFareCents,
+					TaxesCents:     fareProduct.CurrencyPrice.TotalTaxesCents,
+					Refundable:     class.Refundable(),
+				}
+				if query.Currency == model.Points {
+					fare.Points = fareProduct.CurrencyPrice.TotalFarePoints
+				}
+				if fareProduct.Refundable != nil {
+					fare.Refundable = *fareProduct.Refundable
 				}
+				fares = append(fares, fare)
+			}
+			if len(fares) == 0 && len(airProduct.FareProducts) > 0 {
+				// Only offers fare classes the query excludes, or no usable fares
+				continue
 			}
 
-			stops := make([]string, len(airProduct.Segments)-1)
-			for i := 0; i < len(stops); i++ {
-				stops[i] = airProduct.Segments[i].DestinationAirportCode
+			stops := make([]string, 0, len(airProduct.Segments)-1)
+			segments := make([]*model.Segment, 0, len(airProduct.Segments))
+			for i, segment := range airProduct.Segments {
+				if segment == nil {
+					break
+				}
+				if i < len(airProduct.Segments)-1 {
+					stops = append(stops, segment.DestinationAirportCode)
+				}
+				segments = append(segments, &model.Segment{
+					FlightNumber:       segment.FlightNumber,
+					OriginAirport:      segment.OriginationAirportCode,
+					DestinationAirport: segment.DestinationAirportCode,
+					DepartureLocalTime: airports.InLocalTime(segment.DepartureDateTime.Time, segment.OriginationAirportCode),
+					ArrivalLocalTime:   airports.InLocalTime(segment.ArrivalDateTime.Time, segment.DestinationAirportCode),
+				})
+			}
+			if len(segments) != len(airProduct.Segments) {
+				warnings = append(warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[].segments[]", Detail: "null segment"})
+				continue
 			}
 
-			firstSegment := airProduct.Segments[0]
-			lastSegment := airProduct.Segments[len(airProduct.Segments)-1]
+			firstSegment := segments[0]
+			lastSegment := segments[len(segments)-1]
 			flight := model.Flight{
-				OriginAirport:      firstSegment.OriginationAirportCode,
-				DestinationAirport: lastSegment.DestinationAirportCode,
-				DepartureLocalTime: firstSegment.DepartureDateTime.Time,
-				ArrivalLocalTime:   lastSegment.ArrivalDateTime.Time,
+				OriginAirport:      firstSegment.OriginAirport,
+				DestinationAirport: lastSegment.DestinationAirport,
+				DepartureLocalTime: firstSegment.DepartureLocalTime,
+				ArrivalLocalTime:   lastSegment.ArrivalLocalTime,
 				Stops:              stops,
 				Fares:              fares,
+				Segments:           segments,
+				PartySize:          query.PartySize(),
 			}
 			flights = append(flights, &flight)
 		}
 	}
-	return flights, nil
+	return flights, dedupeWarnings(warnings), nil
 }
 
 func parseUrlOrPanic(urlStr string) *url.URL {
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of client errors. Check for them with errors.Is, and use errors.As
+// with *RequestError for the status code, route and date.
+var (
+	// Southwest responded 429 Too Many Requests.
+	ErrRateLimited = errors.New("rate limited by Southwest API")
+
+	// A network error or 5xx response. Usually transient.
+	ErrUpstreamUnavailable = errors.New("Southwest API unavailable")
+
+	// An airport code that is malformed or not served.
+	ErrInvalidAirport = errors.New("invalid airport")
+
+	// Any other non-200 response.
+	ErrUnexpectedStatus = errors.New("unexpected response status")
+
+	// A response body that could not be decoded into flights.
+	ErrUnexpectedResponseShape = errors.New("unexpected response shape")
+)
+
+// Error listing flights. Error text never includes request headers,
+// so API keys don't leak into logs.
+type RequestError struct {
+	// One of the Err* kinds above, or nil, e.g. when the context is cancelled.
+	Kind error
+
+	// Underlying error, if any.
+	Cause error
+
+	Origin        string
+	Destination   string
+	DepartureDate time.Time
+
+	// HTTP status of the response, or 0 if there was no response.
+	StatusCode int
+
+	// Body of a non-200 response.
+	Body string
+
+	// Delay requested by the Retry-After header of a 429 or 503 response, or 0.
+	RetryAfter time.Duration
+}
+
+func (r *RequestError) Error() string {
+	var b strings.Builder
+	b.WriteString("Error listing flights")
+	if r.Origin != "" || r.Destination != "" {
+		fmt.Fprintf(&b, " %s-%s", r.Origin, r.Destination)
+	}
+	if !r.DepartureDate.IsZero() {
+		fmt.Fprintf(&b, " on %s", r.DepartureDate.Format("2006-01-02"))
+	}
+	if r.Kind != nil {
+		fmt.Fprintf(&b, ": %v", r.Kind)
+	}
+	if r.Cause != nil {
+		fmt.Fprintf(&b, ": %v", r.Cause)
+	}
+	if r.StatusCode != 0 {
+		fmt.Fprintf(&b, " (statusCode=%d)", r.StatusCode)
+	}
+	return b.String()
+}
+
+func (r *RequestError) Is(target error) bool {
+	return r.Kind != nil && r.Kind == target
+}
+
+func (r *RequestError) Unwrap() error {
+	return r.Cause
+}
+
+// Whether retrying the same request may succeed.
+func (r *RequestError) Temporary() bool {
+	return r.Kind == ErrRateLimited || r.Kind == ErrUpstreamUnavailable
+}
+
+// Add the route and date to err, converting it to a *RequestError if needed.
+func withRoute(err error, origin string, destination string, departureDate time.Time) error {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		withRoute := *reqErr
+		reqErr = &withRoute
+	} else {
+		reqErr = &RequestError{Cause: err}
+	}
+
+	reqErr.Origin = origin
+	reqErr.Destination = destination
+	reqErr.DepartureDate = departureDate
+	return reqErr
+}
+
+// Some route pairs of a search failed. The search still returns the flights of the others.
+type PartialError struct {
+	// Failed pairs, in search order.
+	Failures []*RequestError
+}
+
+func (p *PartialError) Error() string {
+	failures := make([]string, len(p.Failures))
+	for i, failure := range p.Failures {
+		failures[i] = failure.Error()
+	}
+	return fmt.Sprintf("%d route pairs failed: %s", len(p.Failures), strings.Join(failures, "; "))
+}
+
+// Matches if any failure matches, e.g. errors.Is(err, ErrRateLimited).
+func (p *PartialError) Is(target error) bool {
+	for _, failure := range p.Failures {
+		if errors.Is(failure, target) {
+			return true
+		}
+	}
+	return false
+}
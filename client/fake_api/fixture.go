@@ -0,0 +1,125 @@
+package fake_api
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/client/api_model"
+)
+
+// Fixture builds a list flights response with a single trip.
+// Modifiers return a copy, so one fixture can be scripted as a series of changes:
+//
+//	base := fake_api.NewFixture().Flight(...)
+//	server.Script(route, fake_api.Respond(base.Response()), fake_api.Respond(base.SetFare(0, 0, 5900).Response()))
+type Fixture struct {
+	response *api_model.ListFlightsResponse
+}
+
+func NewFixture() *Fixture {
+	return &Fixture{
+		response: &api_model.ListFlightsResponse{
+			Trips: []*api_model.Trip{{AirProducts: []*api_model.AirProducts{}}},
+		},
+	}
+}
+
+// FixtureFromResponse wraps an existing response, e.g. one loaded from a file.
+func FixtureFromResponse(response *api_model.ListFlightsResponse) *Fixture {
+	return (&Fixture{response}).copy()
+}
+
+type FixtureSegment struct {
+	Origin       string
+	Destination  string
+	Departure    time.Time
+	Arrival      time.Time
+	FlightNumber string
+}
+
+type FixtureFare struct {
+	FareType       string
+	Cents          uint32
+	SeatsAvailable uint32
+	Points         uint32
+	BaseFareCents  uint32
+	TaxesCents     uint32
+}
+
+// Flight appends a flight made of one or more segments.
+func (f *Fixture) Flight(segments []FixtureSegment, fares ...FixtureFare) *Fixture {
+	c := f.copy()
+	product := &api_model.AirProducts{}
+	for _, segment := range segments {
+		product.Segments = append(product.Segments, &api_model.Segment{
+			FlightNumber:           segment.FlightNumber,
+			OriginationAirportCode: segment.Origin,
+			DestinationAirportCode: segment.Destination,
+			DepartureDateTime:      api_model.LocalDateTime{segment.Departure},
+			ArrivalDateTime:        api_model.LocalDateTime{segment.Arrival},
+		})
+	}
+	for _, fare := range fares {
+		product.FareProducts = append(product.FareProducts, &api_model.FareProduct{
+			FareType: fare.FareType,
+			CurrencyPrice: &api_model.CurrencyPrice{
+				TotalFareCents:  fare.Cents,
+				TotalFarePoints: fare.Points,
+				BaseFareCents:   fare.BaseFareCents,
+				TotalTaxesCents: fare.TaxesCents,
+			},
+			SeatsAvailable: strconv.FormatUint(uint64(fare.SeatsAvailable), 10),
+		})
+	}
+	c.products().AirProducts = append(c.products().AirProducts, product)
+	return c
+}
+
+// NonstopFlight appends a flight with a single segment and no flight number.
+func (f *Fixture) NonstopFlight(origin, destination string, departure, arrival time.Time, fares ...FixtureFare) *Fixture {
+	return f.Flight([]FixtureSegment{{Origin: origin, Destination: destination, Departure: departure, Arrival: arrival}}, fares...)
+}
+
+// SetFare changes the price of a fare on the flight at index flight.
+func (f *Fixture) SetFare(flight, fare int, cents uint32) *Fixture {
+	c := f.copy()
+	c.products().AirProducts[flight].FareProducts[fare].CurrencyPrice.TotalFareCents = cents
+	return c
+}
+
+// SoldOut sets the seats available of a fare to zero.
+func (f *Fixture) SoldOut(flight, fare int) *Fixture {
+	c := f.copy()
+	c.products().AirProducts[flight].FareProducts[fare].SeatsAvailable = "0"
+	return c
+}
+
+// RemoveFlight drops the flight at index flight.
+func (f *Fixture) RemoveFlight(flight int) *Fixture {
+	c := f.copy()
+	products := c.products().AirProducts
+	c.products().AirProducts = append(products[:flight:flight], products[flight+1:]...)
+	return c
+}
+
+func (f *Fixture) Response() *api_model.ListFlightsResponse {
+	return f.copy().response
+}
+
+func (f *Fixture) products() *api_model.Trip {
+	return f.response.Trips[0]
+}
+
+// Deep copy via JSON, which also checks the fixture round trips through the client's decoding.
+func (f *Fixture) copy() *Fixture {
+	data, err := json.Marshal(f.response)
+	if err != nil {
+		panic(err)
+	}
+	response := &api_model.ListFlightsResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		panic(err)
+	}
+	return &Fixture{response}
+}
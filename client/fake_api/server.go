@@ -0,0 +1,170 @@
+package fake_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"github.com/alecholmes/southwest_flight_watcher/client/api_model"
+)
+
+const listFlightsPath = "/v1/mobile/flights/products"
+
+// Route identifies a single list flights request.
+// DepartureDate is formatted as 2006-01-02.
+//
+// A route with only Origin, Destination and DepartureDate set matches requests for any
+// passengers, currency and promo code, unless a route with those set is also scripted.
+// Currency is "Dollars" or "Points", as sent by the client.
+type Route struct {
+	Origin        string
+	Destination   string
+	DepartureDate string
+
+	PromoCode        string
+	Currency         string
+	AdultPassengers  int
+	SeniorPassengers int
+}
+
+func (r Route) String() string {
+	if r == r.withoutQuery() {
+		return fmt.Sprintf("%s-%s@%s", r.Origin, r.Destination, r.DepartureDate)
+	}
+	return fmt.Sprintf("%s-%s@%s (%s, %d adults, %d seniors, promo %q)",
+		r.Origin, r.Destination, r.DepartureDate, r.Currency, r.AdultPassengers, r.SeniorPassengers, r.PromoCode)
+}
+
+// Route matching any passengers, currency and promo code.
+func (r Route) withoutQuery() Route {
+	return Route{Origin: r.Origin, Destination: r.Destination, DepartureDate: r.DepartureDate}
+}
+
+// A scripted reply. If Status is 0 or 200, Response is served as JSON.
+// Otherwise, Status is returned with Body. Header is added to either, e.g. a Retry-After.
+type Step struct {
+	Status   int
+	Body     string
+	Header   http.Header
+	Response *api_model.ListFlightsResponse
+}
+
+func Respond(response *api_model.ListFlightsResponse) Step {
+	return Step{Status: http.StatusOK, Response: response}
+}
+
+func Fail(status int) Step {
+	return Step{Status: status, Body: http.StatusText(status)}
+}
+
+// Fake of the Southwest list flights endpoint, for driving client.Client
+// without a network.
+//
+// Each route has a script of steps. Each request for the route consumes the next step,
+// and the last step is repeated once the script runs out. Routes without a script get a 404.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	scripts map[Route][]Step
+	calls   map[Route]int
+}
+
+// NewServer starts a fake server. Callers must Close it.
+func NewServer() *Server {
+	s := &Server{
+		scripts: make(map[Route][]Step),
+		calls:   make(map[Route]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Script replaces the steps for a route.
+func (s *Server) Script(route Route, steps ...Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[route] = steps
+}
+
+// Then appends steps to a route's script, e.g. a price drop for the next cycle.
+func (s *Server) Then(route Route, steps ...Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[route] = append(s.scripts[route], steps...)
+}
+
+// Calls returns the number of requests served by a route's script, or received for
+// a route without one.
+func (s *Server) Calls(route Route) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[route]
+}
+
+// Next step of the most specific script matching route.
+func (s *Server) nextStep(route Route) (Step, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.scripts[route]; !ok {
+		route = route.withoutQuery()
+	}
+	s.calls[route]++
+	steps, ok := s.scripts[route]
+	if !ok || len(steps) == 0 {
+		return Step{}, false
+	}
+	step := steps[0]
+	if len(steps) > 1 {
+		s.scripts[route] = steps[1:]
+	}
+	return step, true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != listFlightsPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	adults, _ := strconv.Atoi(query.Get("number-adult-passengers"))
+	seniors, _ := strconv.Atoi(query.Get("number-senior-passengers"))
+	route := Route{
+		Origin:           query.Get("origination-airport"),
+		Destination:      query.Get("destination-airport"),
+		DepartureDate:    query.Get("departure-date"),
+		PromoCode:        query.Get("promo-code"),
+		Currency:         query.Get("currency-type"),
+		AdultPassengers:  adults,
+		SeniorPassengers: seniors,
+	}
+
+	step, ok := s.nextStep(route)
+	if !ok {
+		http.Error(w, fmt.Sprintf("No fixture for %s", route), http.StatusNotFound)
+		return
+	}
+
+	for name, values := range step.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	if step.Status != 0 && step.Status != http.StatusOK {
+		http.Error(w, step.Body, step.Status)
+		return
+	}
+
+	body, err := json.Marshal(step.Response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+const DefaultMaxConcurrency = 4
+
+type routePair struct {
+	origin      string
+	destination string
+}
+
+func routePairs(originAirports []string, destinationAirports []string) []routePair {
+	pairs := make([]routePair, 0, len(originAirports)*len(destinationAirports))
+	for _, originAirport := range originAirports {
+		for _, destinationAirport := range destinationAirports {
+			pairs = append(pairs, routePair{originAirport, destinationAirport})
+		}
+	}
+	return pairs
+}
+
+// List flights for every pair on a pool of up to maxConcurrency workers.
+// Requests still pass through the rate limiter, so this bounds in flight requests
+// while the limiter bounds their rate.
+// Results are in pair order regardless of completion order.
+// Pairs failing with a known kind of *RequestError are returned as a *PartialError
+// along with the flights of the other pairs. Any other error, e.g. cancellation,
+// is fatal: it cancels the remaining pairs and is returned alone.
+func (c *Client) listFlightsForPairs(ctx context.Context, departureDate time.Time, query FareQuery, pairs []routePair) ([]*model.Flight, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]*model.Flight, len(pairs))
+	failures := make([]*RequestError, len(pairs))
+
+	workers := c.maxConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pairs) {
+		workers = len(pairs)
+	}
+
+	var firstErr error
+	var errOnce sync.Once
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				flights, err := c.ListFlights(ctx, departureDate, pairs[i].origin, pairs[i].destination, query)
+				if reqErr, ok := err.(*RequestError); ok && reqErr.Kind != nil {
+					c.logger.Printf("Skipping failed route pair: %v", reqErr)
+					failures[i] = reqErr
+					continue
+				} else if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				results[i] = flights
+			}
+		}()
+	}
+
+feed:
+	for i := range pairs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	flights := make([]*model.Flight, 0)
+	partialErr := &PartialError{}
+	for i, someFlights := range results {
+		if failures[i] != nil {
+			partialErr.Failures = append(partialErr.Failures, failures[i])
+		}
+		flights = append(flights, someFlights...)
+	}
+	if len(partialErr.Failures) > 0 {
+		return flights, partialErr
+	}
+	return flights, nil
+}
@@ -0,0 +1,306 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/airports"
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+// And matches flights matching all of filters. It matches every flight if filters is empty.
+func And(filters ...FlightFilter) FlightFilter {
+	return andFilter(filters)
+}
+
+// Or matches flights matching any of filters. It matches no flights if filters is empty.
+func Or(filters ...FlightFilter) FlightFilter {
+	return orFilter(filters)
+}
+
+// Not matches flights that filter doesn't.
+func Not(filter FlightFilter) FlightFilter {
+	return &notFilter{filter}
+}
+
+type andFilter []FlightFilter
+
+func (a andFilter) Matches(flight *model.Flight) bool {
+	for _, filter := range a {
+		if !filter.Matches(flight) {
+			return false
+		}
+	}
+	return true
+}
+
+type orFilter []FlightFilter
+
+func (o orFilter) Matches(flight *model.Flight) bool {
+	for _, filter := range o {
+		if filter.Matches(flight) {
+			return true
+		}
+	}
+	return false
+}
+
+type notFilter struct {
+	filter FlightFilter
+}
+
+func (n *notFilter) Matches(flight *model.Flight) bool {
+	return !n.filter.Matches(flight)
+}
+
+// Builds a named filter from its JSON params, which are nil if none were given.
+type FilterFactory func(params json.RawMessage) (FlightFilter, error)
+
+var filterRegistry = map[string]FilterFactory{
+	"nonstop": func(params json.RawMessage) (FlightFilter, error) {
+		if err := decodeFilterParams(params, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return &MaxStopsFilter{0}, nil
+	},
+	"max_stops": func(params json.RawMessage) (FlightFilter, error) {
+		var p struct {
+			Stops *int `json:"stops"`
+		}
+		if err := decodeFilterParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Stops == nil {
+			return nil, fmt.Errorf("stops is required")
+		}
+		return &MaxStopsFilter{*p.Stops}, nil
+	},
+	"max_fare_cents": func(params json.RawMessage) (FlightFilter, error) {
+		var p struct {
+			Cents *uint32 `json:"cents"`
+		}
+		if err := decodeFilterParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Cents == nil {
+			return nil, fmt.Errorf("cents is required")
+		}
+		return &MaxAvailableFareFilter{*p.Cents}, nil
+	},
+	"max_fare_points": func(params json.RawMessage) (FlightFilter, error) {
+		var p struct {
+			Points *uint32 `json:"points"`
+		}
+		if err := decodeFilterParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Points == nil {
+			return nil, fmt.Errorf("points is required")
+		}
+		return &MaxAvailableFarePointsFilter{*p.Points}, nil
+	},
+	"max_duration": func(params json.RawMessage) (FlightFilter, error) {
+		d, err := durationParam(params, "duration")
+		if err != nil {
+			return nil, err
+		}
+		return &MaxDurationFilter{d}, nil
+	},
+	"max_layover": func(params json.RawMessage) (FlightFilter, error) {
+		d, err := durationParam(params, "layover")
+		if err != nil {
+			return nil, err
+		}
+		return &MaxLayoverFilter{d}, nil
+	},
+	"min_connection": func(params json.RawMessage) (FlightFilter, error) {
+		d, err := durationParam(params, "connection")
+		if err != nil {
+			return nil, err
+		}
+		return &MinConnectionFilter{d}, nil
+	},
+	"excluded_connections": func(params json.RawMessage) (FlightFilter, error) {
+		var p struct {
+			Airports []string `json:"airports"`
+		}
+		if err := decodeFilterParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.Airports) == 0 {
+			return nil, fmt.Errorf("airports is required")
+		}
+		codes, err := airports.Expand(p.Airports)
+		if err != nil {
+			return nil, err
+		}
+		return &ExcludedConnectionsFilter{codes}, nil
+	},
+}
+
+// RegisterFilter adds a named filter for use in a FilterSpec, replacing any with the same name.
+// It is not safe to call concurrently with building filters, so call it during initialization.
+func RegisterFilter(name string, factory FilterFactory) {
+	filterRegistry[name] = factory
+}
+
+// Names of all registered filters, sorted.
+func FilterNames() []string {
+	names := make([]string, 0, len(filterRegistry))
+	for name := range filterRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFilter builds a registered filter by name.
+func NewFilter(name string, params json.RawMessage) (FlightFilter, error) {
+	factory, ok := filterRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown filter %q", name)
+	}
+	filter, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid params for filter %q: %v", name, err)
+	}
+	return filter, nil
+}
+
+func decodeFilterParams(params json.RawMessage, p interface{}) error {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(p)
+}
+
+// Decode params holding only a required duration, e.g. {"layover": "2h"}.
+func durationParam(params json.RawMessage, name string) (time.Duration, error) {
+	var p map[string]json.RawMessage
+	if err := decodeFilterParams(params, &p); err != nil {
+		return 0, err
+	}
+	for key := range p {
+		if key != name {
+			return 0, fmt.Errorf("json: unknown field %q", key)
+		}
+	}
+
+	var value *string
+	if raw, ok := p[name]; ok {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return 0, err
+		}
+	}
+	if value == nil {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+	return time.ParseDuration(*value)
+}
+
+// JSON representation of a filter expression. Exactly one field is set, e.g.
+//
+//	{"or": [{"filter": "nonstop"}, {"and": [{"filter": "max_stops", "params": {"stops": 1}}, {"filter": "max_fare_cents", "params": {"cents": 9000}}]}]}
+type FilterSpec struct {
+	And    []*FilterSpec   `json:"and,omitempty"`
+	Or     []*FilterSpec   `json:"or,omitempty"`
+	Not    *FilterSpec     `json:"not,omitempty"`
+	Filter string          `json:"filter,omitempty"` // name of a registered filter
+	Params json.RawMessage `json:"params,omitempty"` // only with filter
+}
+
+// Build the filter the spec describes.
+func (f *FilterSpec) Build() (FlightFilter, error) {
+	set := 0
+	for _, isSet := range []bool{f.And != nil, f.Or != nil, f.Not != nil, f.Filter != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("Filter must have exactly one of and, or, not and filter")
+	}
+	if f.Params != nil && f.Filter == "" {
+		return nil, fmt.Errorf("Filter params require a filter name")
+	}
+
+	switch {
+	case f.And != nil:
+		filters, err := buildFilterSpecs(f.And)
+		if err != nil {
+			return nil, err
+		}
+		return And(filters...), nil
+	case f.Or != nil:
+		filters, err := buildFilterSpecs(f.Or)
+		if err != nil {
+			return nil, err
+		}
+		return Or(filters...), nil
+	case f.Not != nil:
+		filter, err := f.Not.Build()
+		if err != nil {
+			return nil, err
+		}
+		return Not(filter), nil
+	default:
+		return NewFilter(f.Filter, f.Params)
+	}
+}
+
+func buildFilterSpecs(specs []*FilterSpec) ([]FlightFilter, error) {
+	filters := make([]FlightFilter, len(specs))
+	for i, spec := range specs {
+		if spec == nil {
+			return nil, fmt.Errorf("Filter can't be null")
+		}
+		filter, err := spec.Build()
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = filter
+	}
+	return filters, nil
+}
+
+// Readable form of the expression, e.g. `nonstop OR (max_stops {"stops":1} AND max_fare_cents {"cents":9000})`
+func (f *FilterSpec) String() string {
+	switch {
+	case f.And != nil:
+		return joinFilterSpecs(f.And, " AND ")
+	case f.Or != nil:
+		return joinFilterSpecs(f.Or, " OR ")
+	case f.Not != nil:
+		return "NOT " + f.Not.nestedString()
+	case len(f.Params) > 0:
+		var params bytes.Buffer
+		if err := json.Compact(&params, f.Params); err != nil {
+			return f.Filter + " " + string(f.Params)
+		}
+		return f.Filter + " " + params.String()
+	default:
+		return f.Filter
+	}
+}
+
+// String, in parentheses if it combines several filters.
+func (f *FilterSpec) nestedString() string {
+	if len(f.And) > 1 || len(f.Or) > 1 {
+		return "(" + f.String() + ")"
+	}
+	return f.String()
+}
+
+func joinFilterSpecs(specs []*FilterSpec, separator string) string {
+	strs := make([]string, len(specs))
+	for i, spec := range specs {
+		strs[i] = spec.nestedString()
+	}
+	return strings.Join(strs, separator)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultBaseUrl   = "https://api-extensions.southwest.com"
+	DefaultApiKey    = "l7xx8d8bfce4ee874269bedc02832674129b"
+	DefaultUserAgent = "Southwest/3.0.26 (iPhone; iOS 9.1; Scale/2.00)"
+)
+
+// Options for NewClientWithOptions. Zero values fall back to the defaults.
+type Options struct {
+	// Base URL of the API, e.g. a mirror or a fake_api.Server.
+	BaseUrl string
+
+	// Value sent in the X-API-Key header.
+	ApiKey string
+
+	UserAgent string
+
+	// Timeout for a single HTTP request. Zero means no timeout.
+	RequestTimeout time.Duration
+
+	// Optional proxy URL for all requests. Requires Transport to be nil or an *http.Transport.
+	ProxyUrl string
+
+	// Optional transport. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+
+	// Retry policy for failed requests. DefaultRetryPolicy is used if MaxAttempts is 0.
+	Retry RetryPolicy
+
+	// Rate of requests allowed across all searches using the client.
+	// DefaultRequestsPerSecond is used if 0. Negative disables rate limiting.
+	RequestsPerSecond float64
+
+	// Number of requests that may be made at once before rate limiting applies.
+	// DefaultBurst is used if 0.
+	Burst int
+
+	// Number of origin and destination pairs a search fetches at once.
+	// DefaultMaxConcurrency is used if 0.
+	MaxConcurrency int
+
+	// Optional cache of responses, so overlapping searches share requests. No caching if nil.
+	Cache ResponseCache
+
+	// Optional file keeping the baseline that response shapes are compared against, so
+	// changes in Southwest's API are reported once, even across restarts. Created if missing.
+	SchemaBaselineFile string
+
+	// Optional logger for retries and other client events. Discarded if nil.
+	Logger *log.Logger
+}
+
+// ProxyTransport returns a copy of transport sending requests through proxyUrl.
+// http.DefaultTransport is used if transport is nil. Wrapping transports, like a
+// recording.Transport, should wrap the result instead.
+func ProxyTransport(proxyUrl string, transport http.RoundTripper) (http.RoundTripper, error) {
+	parsedUrl, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid proxy URL %s: %v", proxyUrl, err)
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpTransport, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("Proxy URL requires an *http.Transport, not %T", transport)
+	}
+	httpTransport = httpTransport.Clone()
+	httpTransport.Proxy = http.ProxyURL(parsedUrl)
+	return httpTransport, nil
+}
+
+// NewClientWithOptions returns a new Southwest API client configured by opts.
+func NewClientWithOptions(opts Options) (*Client, error) {
+	if opts.BaseUrl == "" {
+		opts.BaseUrl = DefaultBaseUrl
+	}
+	if opts.ApiKey == "" {
+		opts.ApiKey = DefaultApiKey
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultRetryPolicy
+	}
+	if opts.RequestsPerSecond == 0 {
+		opts.RequestsPerSecond = DefaultRequestsPerSecond
+	}
+	if opts.Burst == 0 {
+		opts.Burst = DefaultBurst
+	}
+	if opts.MaxConcurrency == 0 {
+		opts.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(ioutil.Discard, "", 0)
+	}
+
+	baseUrl, err := url.Parse(opts.BaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid base URL %s: %v", opts.BaseUrl, err)
+	}
+
+	transport := opts.Transport
+	if opts.ProxyUrl != "" {
+		if transport, err = ProxyTransport(opts.ProxyUrl, transport); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Client{
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   opts.RequestTimeout,
+		},
+		baseUrl:     baseUrl,
+		apiKey:      opts.ApiKey,
+		userAgent:   opts.UserAgent,
+		retryPolicy: opts.Retry,
+		limiter:     newRateLimiter(opts.RequestsPerSecond, opts.Burst),
+		logger:      opts.Logger,
+
+		maxConcurrency: opts.MaxConcurrency,
+
+		cache: opts.Cache,
+	}
+	if opts.SchemaBaselineFile != "" {
+		if err := c.schema.loadBaseline(opts.SchemaBaselineFile); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+// List flights, also fetching regular prices if query has a promo code.
+// Promo code flights keep their regular fares so callers can see the savings.
+// If Southwest rejects the code, regular flights are returned, marked PromoCodeRejected.
+func (c *Client) listFlightsWithPromoCode(ctx context.Context, departureDate time.Time, originAirport string, destinationAirport string, query FareQuery) ([]*model.Flight, error) {
+	if query.PromoCode == "" {
+		return c.listFlights(ctx, departureDate, originAirport, destinationAirport, query)
+	}
+
+	regularQuery := query
+	regularQuery.PromoCode = ""
+	regularFlights, err := c.listFlights(ctx, departureDate, originAirport, destinationAirport, regularQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	flights, err := c.listFlights(ctx, departureDate, originAirport, destinationAirport, query)
+	if promoCodeRejected(err) {
+		c.logger.Printf("Promo code rejected for %s-%s: %v", originAirport, destinationAirport, err)
+		for _, flight := range regularFlights {
+			flight.PromoCode = query.PromoCode
+			flight.PromoCodeRejected = true
+		}
+		return regularFlights, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	regularFares := make(map[model.FlightId][]*model.Fare, len(regularFlights))
+	for _, flight := range regularFlights {
+		regularFares[*flight.Id()] = flight.Fares
+	}
+	for _, flight := range flights {
+		flight.PromoCode = query.PromoCode
+		flight.RegularFares = regularFares[*flight.Id()]
+	}
+	return flights, nil
+}
+
+// Whether err is a client error response to a promo code request.
+// The regular request for the same route has already succeeded, so the code is the likely cause.
+func promoCodeRejected(err error) bool {
+	reqErr, ok := err.(*RequestError)
+	return ok && reqErr.Kind == ErrUnexpectedStatus &&
+		reqErr.StatusCode >= http.StatusBadRequest && reqErr.StatusCode < http.StatusInternalServerError
+}
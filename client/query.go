@@ -0,0 +1,61 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/alecholmes/southwest_flight_watcher/model"
+)
+
+// Fare parameters sent with each list flights request.
+// The zero value searches for one adult, and keeps fares of every class.
+type FareQuery struct {
+	AdultPassengers  uint8
+	SeniorPassengers uint8
+
+	// Price fares in dollars or Rapid Rewards points. Defaults to dollars.
+	Currency model.Currency
+
+	// Optional promo code to price fares with.
+	PromoCode string
+
+	// Fare classes to keep, or all if empty. Not sent with the request;
+	// other fares are dropped from the response, along with flights left without fares.
+	FareClasses []model.FareClass
+}
+
+// Number of passengers fares must have seats for.
+func (q FareQuery) PartySize() uint32 {
+	if q.AdultPassengers == 0 && q.SeniorPassengers == 0 {
+		return 1
+	}
+	return uint32(q.AdultPassengers) + uint32(q.SeniorPassengers)
+}
+
+func (q FareQuery) allowsFareClass(class model.FareClass) bool {
+	if len(q.FareClasses) == 0 {
+		return true
+	}
+	for _, allowed := range q.FareClasses {
+		if class == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (q FareQuery) setQueryValues(values url.Values) {
+	adults := q.AdultPassengers
+	if adults == 0 && q.SeniorPassengers == 0 {
+		adults = 1
+	}
+
+	if q.Currency == model.Points {
+		values.Set("currency-type", "Points")
+	} else {
+		values.Set("currency-type", "Dollars")
+	}
+	values.Set("number-adult-passengers", strconv.Itoa(int(adults)))
+	values.Set("number-senior-passengers", strconv.Itoa(int(q.SeniorPassengers)))
+	values.Set("promo-code", q.PromoCode)
+}
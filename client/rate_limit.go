@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRequestsPerSecond = 2.0
+	DefaultBurst             = 5
+)
+
+// Token bucket shared by every request made through a client.
+// A nil *rateLimiter does not limit.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Take a token, blocking until one is available or ctx is done.
+// Returns the time spent waiting.
+func (r *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if r == nil {
+		return 0, ctx.Err()
+	}
+
+	delay := r.reserve(time.Now())
+	return delay, sleep(ctx, delay)
+}
+
+// Take a token as of now, returning how long the caller must wait before using it.
+// Tokens may go negative, which queues callers in the order they reserved.
+func (r *rateLimiter) reserve(now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.After(r.last) {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+		r.last = now
+	}
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
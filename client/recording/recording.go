@@ -0,0 +1,93 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alecholmes/southwest_flight_watcher/client/api_model"
+)
+
+// A recorded request and response, as saved in a fixture file.
+type Recording struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+
+	// Body is kept as JSON when valid so fixtures are readable, otherwise as text.
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+func newRecording(req *http.Request, resp *http.Response, body []byte) *Recording {
+	recording := &Recording{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.Query().Encode(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	if json.Valid(body) {
+		recording.Body = body
+	} else {
+		recording.BodyText = string(body)
+	}
+	return recording
+}
+
+// ReadRecording reads a fixture file.
+func ReadRecording(path string) (*Recording, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	recording := &Recording{}
+	if err := json.Unmarshal(data, recording); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
+
+// ListFlightsResponse decodes the recorded body, e.g. for regression testing
+// the conversion to model.Flight.
+func (r *Recording) ListFlightsResponse() (*api_model.ListFlightsResponse, error) {
+	response := &api_model.ListFlightsResponse{}
+	if err := json.Unmarshal(r.body(), response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (r *Recording) body() []byte {
+	if len(r.Body) > 0 {
+		return r.Body
+	}
+	return []byte(r.BodyText)
+}
+
+func (r *Recording) response(req *http.Request) *http.Response {
+	body := r.body()
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
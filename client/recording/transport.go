@@ -0,0 +1,134 @@
+package recording
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type Mode int
+
+const (
+	// Pass requests through to the network and save each request and response.
+	Record Mode = iota
+
+	// Serve saved responses without touching the network.
+	Replay
+)
+
+// http.RoundTripper that records responses to, or replays them from, a fixture directory.
+// Use it as client.Options.Transport, or as the Transport of the *http.Client passed to client.NewClient.
+//
+// Recordings are keyed by method, path and query. Request headers are never saved,
+// so API keys stay out of fixtures.
+type Transport struct {
+	mode Mode
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+var _ http.RoundTripper = &Transport{}
+
+// NewRecorder returns a transport that sends requests with next and saves them to dir,
+// creating it if needed. http.DefaultTransport is used if next is nil.
+func NewRecorder(dir string, next http.RoundTripper) (*Transport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{mode: Record, dir: dir, next: next}, nil
+}
+
+// NewReplayer returns a transport that serves recordings from dir.
+// Requests without a recording fail.
+func NewReplayer(dir string) (*Transport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Replay path %s is not a directory", dir)
+	}
+	return &Transport{mode: Replay, dir: dir}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(t.dir, recordingFilename(req))
+
+	if t.mode == Replay {
+		recording, err := ReadRecording(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No recording %s in %s", filepath.Base(path), t.dir)
+		} else if err != nil {
+			return nil, err
+		}
+		return recording.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := t.write(path, newRecording(req, resp, body)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *Transport) write(path string, recording *Recording) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(recording); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// File name with the route and date when present, so fixtures are easy to find, e.g.
+// SFO-BUR-2016-01-31-1a2b3c4d.json. The hash covers the rest of the request.
+func recordingFilename(req *http.Request) string {
+	query := req.URL.Query()
+	parts := make([]string, 0, 4)
+	for _, param := range []string{"origination-airport", "destination-airport", "departure-date"} {
+		if value := query.Get(param); value != "" {
+			parts = append(parts, sanitize(value))
+		}
+	}
+
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.Path + "?" + query.Encode()))
+	parts = append(parts, hex.EncodeToString(sum[:4]))
+	return strings.Join(parts, "-") + ".json"
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy for retrying failed requests with capped exponential backoff and jitter.
+// Network errors, 429 and 5xx responses are retried. Other 4xx responses are not.
+type RetryPolicy struct {
+	// Total number of attempts, including the first. 1 disables retries.
+	MaxAttempts int
+
+	// Backoff before the first retry. Doubles with each further retry.
+	InitialBackoff time.Duration
+
+	// Cap on the backoff. A longer Retry-After is still waited for, unless it
+	// ends after the request context's deadline, in which case the request fails instead.
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// Backoff before retry number retry, starting at 1. Full backoff is halved and
+// then topped up by a random amount, so concurrent clients don't retry in lockstep.
+func (r RetryPolicy) backoff(retry int) time.Duration {
+	backoff := r.InitialBackoff
+	for i := 1; i < retry && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// GET a URL, retrying according to the client's retry policy.
+// Stops retrying once ctx is done.
+func (c *Client) getWithRetry(ctx context.Context, urlStr string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := c.get(ctx, urlStr)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		reqErr, ok := err.(*RequestError)
+		if !ok || !reqErr.Temporary() {
+			return nil, err
+		}
+
+		wait := c.retryPolicy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && reqErr.RetryAfter > time.Until(deadline) {
+			c.logger.Printf("Not retrying, Retry-After %v is past the deadline: %v", reqErr.RetryAfter, err)
+			return nil, err
+		}
+		if reqErr.RetryAfter > wait {
+			wait = reqErr.RetryAfter
+		}
+
+		if attempt >= c.retryPolicy.MaxAttempts {
+			if attempt > 1 {
+				c.logger.Printf("Giving up after %d attempts: %v", attempt, err)
+			}
+			return nil, err
+		}
+
+		c.logger.Printf("Attempt %d of %d failed, retrying in %v: %v", attempt, c.retryPolicy.MaxAttempts, wait, err)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Sleep for d, returning early with an error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Single GET of a URL, after waiting on the rate limiter.
+// Network errors and non-200 responses are returned as a *RequestError.
+func (c *Client) get(ctx context.Context, urlStr string) ([]byte, error) {
+	waited, err := c.limiter.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if waited > 0 {
+		c.logger.Printf("Rate limiter delayed request by %v", waited)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Add(apiKeyHeader, c.apiKey)
+	httpReq.Header.Add(userAgentHeader, c.userAgent)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &RequestError{Kind: ErrUpstreamUnavailable, Cause: err}
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(httpResp.Body)
+		reqErr := &RequestError{
+			Kind:       ErrUnexpectedStatus,
+			StatusCode: httpResp.StatusCode,
+			Body:       string(body),
+		}
+		if httpResp.StatusCode == http.StatusTooManyRequests {
+			reqErr.Kind = ErrRateLimited
+		} else if httpResp.StatusCode >= 500 {
+			reqErr.Kind = ErrUpstreamUnavailable
+		}
+		if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode == http.StatusServiceUnavailable {
+			reqErr.RetryAfter = parseRetryAfter(httpResp.Header.Get("Retry-After"))
+		}
+		return nil, reqErr
+	} else if httpResp.Body == nil {
+		return nil, &RequestError{Kind: ErrUnexpectedResponseShape, Cause: errors.New("no body"), StatusCode: httpResp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, &RequestError{Kind: ErrUpstreamUnavailable, Cause: err, StatusCode: httpResp.StatusCode}
+	}
+	return body, nil
+}
+
+// Parse a Retry-After header, either delay seconds or an HTTP date.
+// Returns 0 if missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
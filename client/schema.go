@@ -0,0 +1,572 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecholmes/southwest_flight_watcher/client/api_model"
+)
+
+type SchemaWarningKind int
+
+const (
+	UnknownField SchemaWarningKind = iota
+	MissingField
+	RenamedField
+	ChangedFieldType
+	InvalidValue // field is present, but its value can't be used
+)
+
+func (k SchemaWarningKind) String() string {
+	switch k {
+	case UnknownField:
+		return "unknown field"
+	case MissingField:
+		return "missing field"
+	case RenamedField:
+		return "renamed field"
+	case ChangedFieldType:
+		return "changed field type"
+	case InvalidValue:
+		return "invalid value"
+	default:
+		return "unexpected shape"
+	}
+}
+
+// A difference between a Southwest response and api_model.
+// Paths use [] for array elements, e.g. "trips[].airProducts[].segments".
+type SchemaWarning struct {
+	Kind   SchemaWarningKind
+	Path   string
+	Detail string
+}
+
+func (w *SchemaWarning) String() string {
+	if w.Detail == "" {
+		return fmt.Sprintf("%v %s", w.Kind, w.Path)
+	}
+	return fmt.Sprintf("%v %s: %s", w.Kind, w.Path, w.Detail)
+}
+
+// A warning is also the error for a response that can't be used at all.
+func (w *SchemaWarning) Error() string {
+	return w.String()
+}
+
+// Shape of a response as "path:type" entries, or "path:missing" for missing fields.
+// Optional fields, and values inside unknown fields or fields of a changed type, are left out,
+// so the shape doesn't change with which flights or fares a response happens to have.
+type responseShape struct {
+	entries    map[string]bool // true if api_model doesn't expect the entry
+	hasFlights bool
+}
+
+// Decode a list flights response, comparing its shape to api_model.
+// Returns the shape of the response, and warnings for fields that are unknown,
+// missing, renamed or of a changed type. Fields tagged omitempty in api_model are optional.
+//
+// Each flight is decoded on its own, so one that can't be read is skipped with an
+// InvalidValue warning rather than failing the whole response. Numbers and strings
+// are converted to the type api_model expects, so a changed field type alone doesn't
+// lose flights.
+func decodeListFlightsResponse(body []byte) (*api_model.ListFlightsResponse, *responseShape, []*SchemaWarning, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, nil, nil, err
+	}
+
+	shape := &responseShape{entries: make(map[string]bool)}
+	warnings := make([]*SchemaWarning, 0)
+	compareShape(reflect.TypeOf(api_model.ListFlightsResponse{}), raw, "", false, shape.entries, &warnings)
+
+	response, err := decodeTrips(raw, &warnings)
+	if err != nil {
+		return nil, shape, dedupeWarnings(warnings), err
+	}
+	for _, trip := range response.Trips {
+		if trip != nil && len(trip.AirProducts) > 0 {
+			shape.hasFlights = true
+		}
+	}
+	return response, shape, dedupeWarnings(warnings), nil
+}
+
+// Decode the trips of a generic response tree, one flight at a time. Type mismatches above
+// the flights have already been warned about by compareShape, and leave those parts out.
+func decodeTrips(raw interface{}, warnings *[]*SchemaWarning) (*api_model.ListFlightsResponse, error) {
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, &SchemaWarning{Kind: ChangedFieldType, Detail: fmt.Sprintf("expected object, got %s", jsonTypeOf(raw))}
+	}
+
+	response := &api_model.ListFlightsResponse{}
+	trips, _ := object["trips"].([]interface{})
+	for _, rawTrip := range trips {
+		tripObject, ok := rawTrip.(map[string]interface{})
+		if !ok {
+			response.Trips = append(response.Trips, nil)
+			continue
+		}
+
+		trip := &api_model.Trip{}
+		products, _ := tripObject["airProducts"].([]interface{})
+		for _, rawProduct := range products {
+			product, err := decodeAirProduct(rawProduct)
+			if err != nil {
+				*warnings = append(*warnings, &SchemaWarning{Kind: InvalidValue, Path: "trips[].airProducts[]", Detail: fmt.Sprintf("flight skipped: %v", err)})
+				continue
+			}
+			trip.AirProducts = append(trip.AirProducts, product)
+		}
+		response.Trips = append(response.Trips, trip)
+	}
+	return response, nil
+}
+
+var airProductsType = reflect.TypeOf(api_model.AirProducts{})
+
+func decodeAirProduct(raw interface{}) (*api_model.AirProducts, error) {
+	data, err := json.Marshal(coerceJsonTypes(airProductsType, raw))
+	if err != nil {
+		return nil, err
+	}
+	product := &api_model.AirProducts{}
+	if err := json.Unmarshal(data, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// Copy of a generic JSON value with numbers and numeric strings converted to the string
+// or number type expected. Other values are left as they are.
+func coerceJsonTypes(expected reflect.Type, value interface{}) interface{} {
+	for expected.Kind() == reflect.Ptr {
+		expected = expected.Elem()
+	}
+	if reflect.PtrTo(expected).Implements(unmarshalerType) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if expected.Kind() != reflect.Struct {
+			return v
+		}
+		fields := jsonFields(expected)
+		coerced := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			if field, ok := fields[key]; ok {
+				coerced[key] = coerceJsonTypes(field.fieldType, fieldValue)
+			} else {
+				coerced[key] = fieldValue
+			}
+		}
+		return coerced
+	case []interface{}:
+		if expected.Kind() != reflect.Slice {
+			return v
+		}
+		coerced := make([]interface{}, len(v))
+		for i, elemValue := range v {
+			coerced[i] = coerceJsonTypes(expected.Elem(), elemValue)
+		}
+		return coerced
+	case json.Number:
+		if expected.Kind() == reflect.String {
+			return v.String()
+		}
+	case string:
+		if expected.Kind() != reflect.Interface && matchesJsonType(expected, "number") {
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return json.Number(v)
+			}
+		}
+	}
+	return value
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Walk a decoded JSON value alongside the type it should decode into, recording its
+// shape entry unless ignored. expected is nil for unknown fields and the values inside them.
+func compareShape(expected reflect.Type, value interface{}, path string, ignored bool, shape map[string]bool, warnings *[]*SchemaWarning) {
+	for expected != nil && expected.Kind() == reflect.Ptr {
+		expected = expected.Elem()
+	}
+
+	jsonType := jsonTypeOf(value)
+	changedType := expected != nil && jsonType != "null" && !matchesJsonType(expected, jsonType)
+	if changedType {
+		*warnings = append(*warnings, &SchemaWarning{
+			Kind:   ChangedFieldType,
+			Path:   path,
+			Detail: fmt.Sprintf("expected %v, got %s", expected.Kind(), jsonType),
+		})
+	}
+	if !ignored {
+		shape[path+":"+jsonType] = expected == nil || changedType
+	}
+	if expected == nil || changedType {
+		// Only the field itself is drift, not whatever it happens to contain
+		expected = nil
+		ignored = true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if expected == nil || reflect.PtrTo(expected).Implements(unmarshalerType) {
+			for key, fieldValue := range v {
+				compareShape(nil, fieldValue, joinPath(path, key), true, shape, warnings)
+			}
+			return
+		}
+
+		fields := jsonFields(expected)
+		unknown := make([]string, 0)
+		for key, fieldValue := range v {
+			field, ok := fields[key]
+			if !ok {
+				unknown = append(unknown, key)
+				compareShape(nil, fieldValue, joinPath(path, key), ignored, shape, warnings)
+				continue
+			}
+			compareShape(field.fieldType, fieldValue, joinPath(path, key), ignored || field.optional, shape, warnings)
+		}
+
+		missing := make([]string, 0)
+		for name, field := range fields {
+			if _, ok := v[name]; !ok && !field.optional {
+				missing = append(missing, name)
+				if !ignored {
+					shape[joinPath(path, name)+":missing"] = true
+				}
+			}
+		}
+		*warnings = append(*warnings, fieldWarnings(path, unknown, missing)...)
+
+	case []interface{}:
+		var elem reflect.Type
+		if expected != nil && expected.Kind() == reflect.Slice {
+			elem = expected.Elem()
+		}
+		for _, elemValue := range v {
+			compareShape(elem, elemValue, path+"[]", ignored, shape, warnings)
+		}
+	}
+}
+
+type jsonField struct {
+	fieldType reflect.Type
+	optional  bool
+}
+
+// Fields of a struct by JSON name. Fields tagged omitempty are optional.
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := make(map[string]jsonField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = field.Name
+		}
+		optional := false
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				optional = true
+			}
+		}
+		fields[name] = jsonField{field.Type, optional}
+	}
+	return fields
+}
+
+// Unknown and missing fields of one object. A pair with names that differ only in case
+// or punctuation is reported once, as renamed.
+func fieldWarnings(path string, unknown, missing []string) []*SchemaWarning {
+	sort.Strings(unknown)
+	sort.Strings(missing)
+
+	warnings := make([]*SchemaWarning, 0)
+	renamed := make(map[string]bool) // both old and new names
+	for _, missingName := range missing {
+		for _, unknownName := range unknown {
+			if !renamed[unknownName] && normalizeFieldName(missingName) == normalizeFieldName(unknownName) {
+				renamed[missingName] = true
+				renamed[unknownName] = true
+				warnings = append(warnings, &SchemaWarning{
+					Kind:   RenamedField,
+					Path:   joinPath(path, missingName),
+					Detail: fmt.Sprintf("now %q", unknownName),
+				})
+				break
+			}
+		}
+	}
+	for _, name := range unknown {
+		if !renamed[name] {
+			warnings = append(warnings, &SchemaWarning{Kind: UnknownField, Path: joinPath(path, name)})
+		}
+	}
+	for _, name := range missing {
+		if !renamed[name] {
+			warnings = append(warnings, &SchemaWarning{Kind: MissingField, Path: joinPath(path, name)})
+		}
+	}
+	return warnings
+}
+
+// e.g. "totalFareCents", "total_fare_cents" and "TotalFareCents" are the same name.
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "null"
+	}
+}
+
+func matchesJsonType(t reflect.Type, jsonType string) bool {
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return jsonType == "object"
+	case reflect.Slice, reflect.Array:
+		return jsonType == "array"
+	case reflect.String:
+		return jsonType == "string"
+	case reflect.Bool:
+		return jsonType == "bool"
+	case reflect.Interface:
+		return true
+	default:
+		return jsonType == "number"
+	}
+}
+
+func dedupeWarnings(warnings []*SchemaWarning) []*SchemaWarning {
+	seen := make(map[string]bool, len(warnings))
+	deduped := make([]*SchemaWarning, 0, len(warnings))
+	for _, warning := range warnings {
+		if !seen[warning.String()] {
+			seen[warning.String()] = true
+			deduped = append(deduped, warning)
+		}
+	}
+	return deduped
+}
+
+// Response shape entries that weren't in the baseline, e.g. "trips[].airProducts[].newField:string".
+// Each entry is reported once, then added to the baseline.
+type SchemaDrift struct {
+	Fingerprint string // of the shape of all responses seen so far
+	Entries     []string
+}
+
+type schemaBaselineFile struct {
+	Entries []string `json:"entries"`
+}
+
+// Shape of all responses seen so far, the warnings they raised, and the baseline that
+// unexpected entries are compared against. Each new warning, and each drift, is logged once.
+//
+// The baseline is loaded from a file if one is set and exists. Otherwise it is seeded from
+// responses up to and including the first with flights, since empty responses don't
+// show most of the shape, and saved to the file if set.
+type schemaTracker struct {
+	mu           sync.Mutex
+	shape        map[string]bool
+	warnings     []*SchemaWarning
+	logged       map[string]bool
+	baselineFile string
+	baseline     map[string]bool
+	seeded       bool
+	drift        []string // not yet taken by TakeSchemaDrift
+}
+
+// Keep the baseline in baselineFile, loading it if the file exists.
+func (s *schemaTracker) loadBaseline(baselineFile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+
+	s.baselineFile = baselineFile
+	data, err := ioutil.ReadFile(baselineFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var file schemaBaselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("Invalid schema baseline file %s: %v", baselineFile, err)
+	}
+	for _, entry := range file.Entries {
+		s.baseline[entry] = true
+	}
+	s.seeded = true
+	return nil
+}
+
+// Caller must hold mu.
+func (s *schemaTracker) init() {
+	if s.shape == nil {
+		s.shape = make(map[string]bool)
+		s.logged = make(map[string]bool)
+		s.baseline = make(map[string]bool)
+	}
+}
+
+// Record a response's shape, which is nil if it has none, and warnings.
+// Returns the drift and warnings not seen before.
+func (s *schemaTracker) record(shape *responseShape, warnings []*SchemaWarning) (newDrift []string, newWarnings []*SchemaWarning, saveErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+
+	if shape != nil {
+		for entry, unexpected := range shape.entries {
+			s.shape[entry] = true
+			if !unexpected || s.baseline[entry] {
+				continue
+			}
+			s.baseline[entry] = true
+			if s.seeded {
+				newDrift = append(newDrift, entry)
+			}
+		}
+		sort.Strings(newDrift)
+		s.drift = append(s.drift, newDrift...)
+
+		if len(newDrift) > 0 || (!s.seeded && shape.hasFlights) {
+			s.seeded = true
+			saveErr = s.saveBaseline()
+		}
+	}
+
+	for _, warning := range warnings {
+		if !s.logged[warning.String()] {
+			s.logged[warning.String()] = true
+			s.warnings = append(s.warnings, warning)
+			newWarnings = append(newWarnings, warning)
+		}
+	}
+	return
+}
+
+// Write the baseline to its file, if any. Writes then renames, so a crash doesn't lose it.
+func (s *schemaTracker) saveBaseline() error {
+	if s.baselineFile == "" {
+		return nil
+	}
+
+	file := schemaBaselineFile{Entries: make([]string, 0, len(s.baseline))}
+	for entry := range s.baseline {
+		file.Entries = append(file.Entries, entry)
+	}
+	sort.Strings(file.Entries)
+	data, err := json.MarshalIndent(&file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.baselineFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.baselineFile)
+}
+
+// Caller must hold mu.
+func (s *schemaTracker) fingerprint() string {
+	if len(s.shape) == 0 {
+		return ""
+	}
+	entries := make([]string, 0, len(s.shape))
+	for entry := range s.shape {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Fingerprint of the shape of all list flights responses seen so far, or "" if none.
+// It only changes when a response has a field or field type not seen before.
+func (c *Client) SchemaFingerprint() string {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	return c.schema.fingerprint()
+}
+
+// Distinct warnings from all list flights responses seen so far.
+func (c *Client) SchemaWarnings() []*SchemaWarning {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	return append([]*SchemaWarning{}, c.schema.warnings...)
+}
+
+// TakeSchemaDrift returns drift from the baseline found since the last call, or nil if none.
+func (c *Client) TakeSchemaDrift() *SchemaDrift {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	if len(c.schema.drift) == 0 {
+		return nil
+	}
+	drift := &SchemaDrift{Fingerprint: c.schema.fingerprint(), Entries: c.schema.drift}
+	c.schema.drift = nil
+	return drift
+}
+
+// Record a response's shape and warnings, logging anything new.
+func (c *Client) checkSchema(shape *responseShape, warnings []*SchemaWarning) {
+	newDrift, newWarnings, err := c.schema.record(shape, warnings)
+	if len(newDrift) > 0 {
+		c.logger.Printf("Southwest response shape changed, fingerprint now %s. New: %s", c.SchemaFingerprint(), strings.Join(newDrift, ", "))
+	}
+	if err != nil {
+		c.logger.Printf("Unable to save schema baseline: %v", err)
+	}
+	for _, warning := range newWarnings {
+		c.logger.Printf("Southwest response schema warning: %v", warning)
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const twoFlightsResponse = `{"trips": [{"airProducts": [
+	{"segments": [{"originationAirportCode": "SFO", "destinationAirportCode": "BUR", "departureDateTime": %s, "arrivalDateTime": "2030-03-01T09:30"}],
+	 "fareProducts": [{"fareType": "WGA", "currencyPrice": {"totalFareCents": 9900}, "seatsAvailable": %s}]},
+	{"segments": [{"originationAirportCode": "SFO", "destinationAirportCode": "BUR", "departureDateTime": "2030-03-01T10:00", "arrivalDateTime": "2030-03-01T11:30"}],
+	 "fareProducts": [{"fareType": "WGA", "currencyPrice": {"totalFareCents": 9900}, "seatsAvailable": %s}]}
+]}]}`
+
+func TestDecodeListFlightsResponse(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		departure string
+		seats     string
+		flights   int
+		warning   string
+	}{
+		{"valid", `"2030-03-01T08:00"`, `"3"`, 2, ""},
+		{"time with seconds", `"2030-03-01T08:00:00"`, `"3"`, 1, "invalid value trips[].airProducts[]: flight skipped"},
+		{"null time", `null`, `"3"`, 1, "invalid value trips[].airProducts[]: flight skipped"},
+		{"seats as number", `"2030-03-01T08:00"`, `3`, 2, "changed field type trips[].airProducts[].fareProducts[].seatsAvailable"},
+	} {
+		body := fmt.Sprintf(twoFlightsResponse, test.departure, test.seats, test.seats)
+		response, _, warnings, err := decodeListFlightsResponse([]byte(body))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		flights, _, err := swListFlightsResponseToFlights(response, FareQuery{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if len(flights) != test.flights {
+			t.Errorf("%s: expected %d flights, got %d", test.name, test.flights, len(flights))
+		}
+
+		found := test.warning == ""
+		for _, warning := range warnings {
+			found = found || strings.HasPrefix(warning.String(), test.warning)
+		}
+		if !found || (test.warning == "" && len(warnings) > 0) {
+			t.Errorf("%s: expected warning %q, got %v", test.name, test.warning, warnings)
+		}
+	}
+}
+
+func TestSchemaTrackerBaseline(t *testing.T) {
+	flight := fmt.Sprintf(twoFlightsResponse, `"2030-03-01T08:00"`, `"3"`, `"3"`)
+	withNewField := strings.Replace(flight, `"fareType": "WGA"`, `"fareType": "WGA", "newField": "x"`, 1)
+	baselineFile := filepath.Join(t.TempDir(), "baseline.json")
+
+	record := func(tracker *schemaTracker, body string) []string {
+		_, shape, warnings, err := decodeListFlightsResponse([]byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		drift, _, err := tracker.record(shape, warnings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return drift
+	}
+
+	// An empty first response doesn't make the fields of later ones drift
+	tracker := &schemaTracker{}
+	if err := tracker.loadBaseline(baselineFile); err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{`{"trips": []}`, flight, flight} {
+		if drift := record(tracker, body); len(drift) > 0 {
+			t.Fatalf("unexpected drift %v", drift)
+		}
+	}
+	if drift := record(tracker, withNewField); len(drift) != 1 || drift[0] != "trips[].airProducts[].fareProducts[].newField:string" {
+		t.Fatalf("expected drift for newField, got %v", drift)
+	}
+	if drift := record(tracker, withNewField); len(drift) > 0 {
+		t.Fatalf("drift reported twice: %v", drift)
+	}
+
+	// The baseline is kept across restarts
+	restarted := &schemaTracker{}
+	if err := restarted.loadBaseline(baselineFile); err != nil {
+		t.Fatal(err)
+	}
+	if drift := record(restarted, withNewField); len(drift) > 0 {
+		t.Fatalf("drift reported again after restart: %v", drift)
+	}
+}
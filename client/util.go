@@ -13,6 +13,6 @@ func normalizeAirportCode(code string) (string, error) {
 	if airportCodeRegex.MatchString(normalizedCode) {
 		return normalizedCode, nil
 	} else {
-		return "", fmt.Errorf("Invalid airport code %s", code)
+		return "", &RequestError{Kind: ErrInvalidAirport, Cause: fmt.Errorf("malformed code %q", code)}
 	}
 }
@@ -2,17 +2,21 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/smtp"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/alecholmes/southwest_flight_watcher/app"
 	"github.com/alecholmes/southwest_flight_watcher/client"
+	"github.com/alecholmes/southwest_flight_watcher/client/recording"
 )
 
 const (
@@ -22,6 +26,15 @@ const (
 	toFlagStr           = "to"
 	smtpFlagStr         = "smtp"
 	smtpPasswordFileStr = "smtpPasswordFile"
+	clientConfigFileStr = "clientConfigFile"
+	apiBaseUrlStr       = "apiBaseUrl"
+	apiKeyStr           = "apiKey"
+	userAgentStr        = "userAgent"
+	requestTimeoutStr   = "requestTimeout"
+	httpProxyStr        = "httpProxy"
+	cycleTimeoutStr     = "cycleTimeout"
+	recordDirStr        = "recordDir"
+	replayDirStr        = "replayDir"
 )
 
 func main() {
@@ -30,6 +43,15 @@ func main() {
 	toFlag := flag.String(toFlagStr, "", "email address to which updates are sent")
 	smtpFlag := flag.String(smtpFlagStr, "", fmt.Sprintf("SMTP host for mail delivery. Port %i is used.", smtpPort))
 	smtpPasswordFileFlag := flag.String(smtpPasswordFileStr, "", "File containing SMTP password. Must have 0700 permissions.")
+	clientConfigFileFlag := flag.String(clientConfigFileStr, "", "optional filename of Southwest API client settings JSON")
+	apiBaseUrlFlag := flag.String(apiBaseUrlStr, "", "optional base URL of the Southwest API. Overrides the client config file.")
+	apiKeyFlag := flag.String(apiKeyStr, "", "optional Southwest API key. Overrides the client config file.")
+	userAgentFlag := flag.String(userAgentStr, "", "optional user agent sent to the Southwest API. Overrides the client config file.")
+	requestTimeoutFlag := flag.Duration(requestTimeoutStr, 0, "optional timeout for each Southwest API request. Overrides the client config file.")
+	httpProxyFlag := flag.String(httpProxyStr, "", "optional HTTP proxy URL for Southwest API requests. Overrides the client config file.")
+	recordDirFlag := flag.String(recordDirStr, "", "optional directory in which to record all Southwest API responses")
+	replayDirFlag := flag.String(replayDirStr, "", "optional directory of recorded Southwest API responses to serve instead of using the network")
+	cycleTimeoutFlag := flag.Duration(cycleTimeoutStr, 10*time.Minute, "deadline for each update cycle, including sending notifications")
 
 	// Check all the flags
 	flag.Parse()
@@ -50,6 +72,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%v flag must be set\n", smtpPasswordFileStr)
 		return
 	}
+	if *recordDirFlag != "" && *replayDirFlag != "" {
+		fmt.Fprintf(os.Stderr, "Only one of %v and %v may be set\n", recordDirStr, replayDirStr)
+		return
+	}
 
 	// Load SMTP password
 	password, err := loadPassword(*smtpPasswordFileFlag)
@@ -64,9 +90,67 @@ func main() {
 		return
 	}
 
-	// Set up channel for OS signals, which are used to shutdown the app
-	sigChannel := make(chan os.Signal, 1)
-	signal.Notify(sigChannel, os.Interrupt, os.Kill)
+	// Build API client, with flags taking precedence over the config file
+	clientConfig := &app.ClientConfig{}
+	if *clientConfigFileFlag != "" {
+		if clientConfig, err = app.ClientConfigFromFile(*clientConfigFileFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid client config file contents: %v\n", err)
+			return
+		}
+	}
+	clientOptions, err := clientConfig.Options()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid client config: %v\n", err)
+		return
+	}
+	if *apiBaseUrlFlag != "" {
+		clientOptions.BaseUrl = *apiBaseUrlFlag
+	}
+	if *apiKeyFlag != "" {
+		clientOptions.ApiKey = *apiKeyFlag
+	}
+	if *userAgentFlag != "" {
+		clientOptions.UserAgent = *userAgentFlag
+	}
+	if *requestTimeoutFlag != 0 {
+		clientOptions.RequestTimeout = *requestTimeoutFlag
+	}
+	if *httpProxyFlag != "" {
+		clientOptions.ProxyUrl = *httpProxyFlag
+	}
+	if *recordDirFlag != "" {
+		// Record through the proxy, if any, rather than proxying the recorder
+		var next http.RoundTripper
+		if clientOptions.ProxyUrl != "" {
+			if next, err = client.ProxyTransport(clientOptions.ProxyUrl, nil); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid client settings: %v\n", err)
+				return
+			}
+			clientOptions.ProxyUrl = ""
+		}
+		if clientOptions.Transport, err = recording.NewRecorder(*recordDirFlag, next); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to record to %v: %v\n", *recordDirFlag, err)
+			return
+		}
+	} else if *replayDirFlag != "" {
+		// Replays never reach the network, so there's nothing to proxy
+		clientOptions.ProxyUrl = ""
+		if clientOptions.Transport, err = recording.NewReplayer(*replayDirFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to replay from %v: %v\n", *replayDirFlag, err)
+			return
+		}
+	}
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	clientOptions.Logger = logger
+	swClient, err := client.NewClientWithOptions(clientOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid client settings: %v\n", err)
+		return
+	}
+
+	// OS signals cancel the context, which shuts down the app and any update in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Create an email notifier
 	to := *toFlag
@@ -83,29 +167,32 @@ func main() {
 	notifier := app.SearchUpdateNotifierChain{&app.StdoutNotifier{}, emailNotifier}
 
 	// Create container for state with the function to update it
-	state := app.NewSearchStateUpdater(searches, app.NewFlightFetcher(client.NewClient(nil)), notifier)
+	state := app.NewSearchStateUpdater(searches, app.NewFlightFetcher(swClient), notifier)
 
-	logger := log.New(os.Stdout, "", log.LstdFlags)
 	updater := func() {
 		logger.Print("Updating flights")
-		if err := state.Update(); err != nil {
+		cycleCtx, cancel := context.WithTimeout(ctx, *cycleTimeoutFlag)
+		defer cancel()
+		if err := state.Update(cycleCtx); err != nil {
 			logger.Printf("Error updating flights: %v\n", err)
 		}
+		cacheStats := swClient.CacheStats()
+		logger.Printf("Response cache totals: hits=%d, misses=%d", cacheStats.Hits, cacheStats.Misses)
+		logger.Printf("Response schema fingerprint: %s, warnings: %d", swClient.SchemaFingerprint(), len(swClient.SchemaWarnings()))
 	}
 
-	// Run immediately, and then every hour
-	// TODO(alec): Determine if sharing state variable across goroutines is bad in this case
+	// Run immediately, and then every hour until signal to shutdown
 	ticker := time.NewTicker(1 * time.Hour)
-	go func() {
-		for _ = range ticker.C {
+	defer ticker.Stop()
+	updater()
+	for {
+		select {
+		case <-ticker.C:
 			updater()
+		case <-ctx.Done():
+			return
 		}
-	}()
-	updater()
-
-	// Keep running until signal to shutdown
-	<-sigChannel
-	ticker.Stop()
+	}
 }
 
 func loadPassword(filename string) (string, error) {
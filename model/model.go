@@ -5,9 +5,67 @@ import (
 	"time"
 )
 
+type Currency int
+
+const (
+	Dollars Currency = iota
+	Points           // Rapid Rewards points
+)
+
+// Southwest fare class, from cheapest and most restrictive to most expensive.
+type FareClass int
+
+const (
+	UnknownFareClass FareClass = iota
+	WannaGetAway
+	WannaGetAwayPlus
+	Anytime
+	BusinessSelect
+)
+
+func (c FareClass) String() string {
+	switch c {
+	case WannaGetAway:
+		return "Wanna Get Away"
+	case WannaGetAwayPlus:
+		return "Wanna Get Away Plus"
+	case Anytime:
+		return "Anytime"
+	case BusinessSelect:
+		return "Business Select"
+	default:
+		return "Unknown"
+	}
+}
+
+// Anytime and Business Select fares can be refunded, others only as travel credit.
+func (c FareClass) Refundable() bool {
+	return c == Anytime || c == BusinessSelect
+}
+
 type Fare struct {
 	Cents          uint32
+	Points         uint32 // only set when Currency is Points
+	Currency       Currency
 	SeatsAvailable uint32
+
+	// Southwest fare type code, e.g. "WGA", and the class it maps to.
+	FareType string
+	Class    FareClass
+
+	// Breakdown of the total. Taxes are in cents for points fares too.
+	BaseFareCents uint32
+	TaxesCents    uint32
+
+	Refundable bool
+}
+
+// Price in the fare's currency, either cents or points.
+func (f *Fare) Amount() uint32 {
+	if f.Currency == Points {
+		return f.Points
+	}
+	return f.Cents
 }
 
 // Compares this fare to another.
@@ -24,12 +82,14 @@ func (f *Fare) Compare(o *Fare) int {
 			return 1
 		}
 	} else {
-		if f.Cents == o.Cents {
+		if o == nil {
+			return -1
+		} else if f.Amount() == o.Amount() {
 			return 0
-		} else if f.Cents > o.Cents {
+		} else if f.Amount() > o.Amount() {
 			return 1
 		} else {
-			return 0
+			return -1
 		}
 	}
 }
@@ -40,8 +100,20 @@ type FlightId struct {
 	departureLocalTime time.Time
 	arrivalLocalTime   time.Time
 	stops              string
+	flightNumbers      string
 }
 
+// One leg of a flight, flown without getting off the plane.
+// Times are in the time zones of their airports.
+type Segment struct {
+	FlightNumber       string
+	OriginAirport      string
+	DestinationAirport string
+	DepartureLocalTime time.Time
+	ArrivalLocalTime   time.Time
+}
+
+// Departure and arrival times are in the time zones of the origin and destination airports.
 type Flight struct {
 	OriginAirport      string
 	DestinationAirport string
@@ -49,6 +121,22 @@ type Flight struct {
 	ArrivalLocalTime   time.Time
 	Stops              []string
 	Fares              []*Fare
+
+	// Legs of the flight in order. Stops are the destinations of all but the last.
+	Segments []*Segment
+
+	// Number of passengers searched for. Fares are per person,
+	// and only available if they have seats for everyone. 0 is treated as 1.
+	PartySize uint32
+
+	// Promo code Fares are priced with, if any.
+	PromoCode string
+
+	// Fares without the promo code, when PromoCode is set.
+	RegularFares []*Fare
+
+	// Southwest rejected the searched promo code, so Fares are regular fares.
+	PromoCodeRejected bool
 }
 
 func (f *Flight) Id() *FlightId {
@@ -58,17 +146,78 @@ func (f *Flight) Id() *FlightId {
 		departureLocalTime: f.DepartureLocalTime,
 		arrivalLocalTime:   f.ArrivalLocalTime,
 		stops:              strings.Join(f.Stops, ":"),
+		flightNumbers:      strings.Join(f.FlightNumbers(), ":"),
 	}
 }
 
+// Elapsed travel time, from departure to arrival.
+func (f *Flight) Duration() time.Duration {
+	return f.ArrivalLocalTime.Sub(f.DepartureLocalTime)
+}
+
+// Flight number of each segment.
+func (f *Flight) FlightNumbers() []string {
+	numbers := make([]string, len(f.Segments))
+	for i, segment := range f.Segments {
+		numbers[i] = segment.FlightNumber
+	}
+	return numbers
+}
+
+// Time between each segment's arrival and the next segment's departure.
+// Both are local to the stop airport, so no time zone is needed.
+func (f *Flight) Layovers() []time.Duration {
+	layovers := make([]time.Duration, 0)
+	for i := 1; i < len(f.Segments); i++ {
+		layovers = append(layovers, f.Segments[i].DepartureLocalTime.Sub(f.Segments[i-1].ArrivalLocalTime))
+	}
+	return layovers
+}
+
+// Cheapest per person fare with seats for the whole party, or nil if none.
 func (f *Flight) CheapestAvailableFare() *Fare {
+	return f.cheapestAvailable(f.Fares)
+}
+
+// Cheapest per person fare without the promo code, or nil if none or there is no promo code.
+func (f *Flight) CheapestAvailableRegularFare() *Fare {
+	return f.cheapestAvailable(f.RegularFares)
+}
+
+// Per person amount the promo code saves over the cheapest regular fare, or 0 if none.
+func (f *Flight) PromoCodeSavings() uint32 {
+	fare := f.CheapestAvailableFare()
+	regularFare := f.CheapestAvailableRegularFare()
+	if fare == nil || regularFare == nil || fare.Amount() >= regularFare.Amount() {
+		return 0
+	}
+	return regularFare.Amount() - fare.Amount()
+}
+
+func (f *Flight) cheapestAvailable(fares []*Fare) *Fare {
 	var cheapestFare *Fare
-	for _, fare := range f.Fares {
-		if fare.SeatsAvailable > 0 {
-			if cheapestFare == nil || fare.Cents < cheapestFare.Cents {
+	for _, fare := range fares {
+		if fare.SeatsAvailable >= f.partySize() {
+			if cheapestFare == nil || fare.Amount() < cheapestFare.Amount() {
 				cheapestFare = fare
 			}
 		}
 	}
 	return cheapestFare
 }
+
+func (f *Flight) partySize() uint32 {
+	if f.PartySize == 0 {
+		return 1
+	}
+	return f.PartySize
+}
+
+// Total of the cheapest available fare for the whole party in the fare's currency, or 0 if none.
+func (f *Flight) CheapestAvailablePartyAmount() uint32 {
+	fare := f.CheapestAvailableFare()
+	if fare == nil {
+		return 0
+	}
+	return fare.Amount() * f.partySize()
+}